@@ -13,6 +13,8 @@ import (
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/apis"
 	"github.com/integr8ly/cloud-resource-operator/pkg/controller"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/webhook"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
@@ -23,11 +25,13 @@ import (
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
+	runtimewebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 // Change below variables to serve metrics on different host or port.
@@ -38,6 +42,18 @@ var (
 )
 var log = logf.Log.WithName("cmd")
 
+var (
+	enableWebhooks = pflag.Bool("enable-webhooks", false, "Serve admission webhooks, requires the operator to run in-cluster")
+	webhookPort    = pflag.Int32("webhook-port", 9443, "Port the admission webhook server listens on")
+	webhookCertDir = pflag.String("webhook-cert-dir", "/tmp/cert", "Directory the admission webhook server certificates are written to")
+	dryRun         = pflag.Bool("dry-run", false, "Only plan changes to cloud resources, planned actions are written to the status of each resource")
+)
+
+const (
+	webhookServiceName = "cloud-resource-operator-webhook"
+	webhookSecretName  = "cloud-resource-operator-webhook-cert"
+)
+
 func printVersion() {
 	log.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	log.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
@@ -67,6 +83,11 @@ func main() {
 
 	printVersion()
 
+	if *dryRun {
+		log.Info("Running in dry-run mode, changes to cloud resources will only be planned")
+	}
+	resources.SetDryRun(*dryRun)
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
@@ -113,6 +134,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks
+	if *enableWebhooks {
+		operatorNs, err := k8sutil.GetOperatorNamespace()
+		if err != nil {
+			log.Error(err, "Failed to get operator namespace")
+			os.Exit(1)
+		}
+		if err := webhook.AddToManager(mgr, buildWebhookServerOptions(operatorNs)); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	if err = serveCRMetrics(cfg); err != nil {
 		log.Info("Could not generate and serve custom resource metrics", "error", err.Error())
 	}
@@ -173,3 +207,27 @@ func serveCRMetrics(cfg *rest.Config) error {
 	}
 	return nil
 }
+
+// buildWebhookServerOptions configures the webhook server to provision its certificate in a secret and to be served
+// through a service in the operator namespace.
+func buildWebhookServerOptions(operatorNs string) runtimewebhook.ServerOptions {
+	return runtimewebhook.ServerOptions{
+		Port:    *webhookPort,
+		CertDir: *webhookCertDir,
+		BootstrapOptions: &runtimewebhook.BootstrapOptions{
+			MutatingWebhookConfigName:   "cloud-resource-operator-mutating",
+			ValidatingWebhookConfigName: "cloud-resource-operator-validating",
+			Secret: &types.NamespacedName{
+				Name:      webhookSecretName,
+				Namespace: operatorNs,
+			},
+			Service: &runtimewebhook.Service{
+				Name:      webhookServiceName,
+				Namespace: operatorNs,
+				Selectors: map[string]string{
+					"name": "cloud-resource-operator",
+				},
+			},
+		},
+	}
+}
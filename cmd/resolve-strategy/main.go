@@ -0,0 +1,61 @@
+// resolve-strategy prints the effective aws strategy of a tier, after the tiers it extends and its region overrides
+// have been merged, e.g.
+//
+//	oc get configmap cloud-resources-aws-strategies -n kube-system -o jsonpath='{.data.blobstorage}' | resolve-strategy --tier development
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/spf13/pflag"
+)
+
+var (
+	file = pflag.String("file", "-", "File containing the strategy mapping of a resource type, - reads from stdin")
+	tier = pflag.String("tier", "", "Tier to resolve the strategy of")
+)
+
+func main() {
+	pflag.Parse()
+	if *tier == "" {
+		exit(fmt.Errorf("--tier must be specified"))
+	}
+
+	var raw []byte
+	var err error
+	if *file == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(*file)
+	}
+	if err != nil {
+		exit(fmt.Errorf("failed to read strategy mapping: %v", err))
+	}
+
+	stratCfg, err := aws.ResolveTier(raw, *tier)
+	if err != nil {
+		exit(err)
+	}
+	if err = aws.ValidateBlobStorageStrategy(stratCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: resolved strategy is not a valid blob storage strategy: %v\n", err)
+	}
+	out, err := json.Marshal(stratCfg)
+	if err != nil {
+		exit(fmt.Errorf("failed to marshal resolved strategy: %v", err))
+	}
+	indented := &bytes.Buffer{}
+	if err = json.Indent(indented, out, "", "  "); err != nil {
+		exit(fmt.Errorf("failed to format resolved strategy: %v", err))
+	}
+	fmt.Println(indented.String())
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -10,6 +11,17 @@ import (
 // SecretRef This represents a namespace-scoped Secret
 type SecretRef struct {
 	Name string `json:"name,omitempty"`
+	// Namespace the Secret is written to, defaults to the namespace of the resource when empty
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CORSRule Allows browsers on the listed origins to call the bucket
+type CORSRule struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  int64    `json:"maxAgeSeconds,omitempty"`
 }
 
 // BlobStorageSpec defines the desired state of BlobStorage
@@ -21,6 +33,13 @@ type BlobStorageSpec struct {
 	Type      string    `json:"type"`
 	Tier      string    `json:"tier"`
 	SecretRef SecretRef `json:"secretRef"`
+	// Overrides are merged onto the strategy of the tier, only fields the tier declares overridable can be set
+	Overrides *runtime.RawExtension `json:"overrides,omitempty"`
+	// CORSRules are added to the cors rules of the tier
+	CORSRules []CORSRule `json:"corsRules,omitempty"`
+	// PolicyStatements are added to the bucket policy statements of the tier, statements allowing any principal are
+	// rejected unless the tier allows public policies
+	PolicyStatements []runtime.RawExtension `json:"policyStatements,omitempty"`
 }
 
 // BlobStorageStatus defines the observed state of BlobStorage
@@ -29,9 +48,15 @@ type BlobStorageStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
-	Strategy  string    `json:"strategy,omitempty"`
-	Provider  string    `json:"provider,omitempty"`
-	SecretRef SecretRef `json:"secretRef,omitempty"`
+	Strategy   string      `json:"strategy,omitempty"`
+	Provider   string      `json:"provider,omitempty"`
+	SecretRef  SecretRef   `json:"secretRef,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	// PlannedActions are the changes the operator would make to cloud resources, only set in dry-run mode
+	PlannedActions []string `json:"plannedActions,omitempty"`
+	// ManagedSettings are the settings of the cloud resource managed by the operator, they're removed from the cloud
+	// resource when they're no longer set
+	ManagedSettings []string `json:"managedSettings,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
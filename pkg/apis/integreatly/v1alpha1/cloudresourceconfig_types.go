@@ -0,0 +1,95 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeploymentStrategies The strategy used to provision each resource type in a deployment type
+type DeploymentStrategies struct {
+	BlobStorage string `json:"blobstorage,omitempty"`
+}
+
+// ProvidersConfig Provider-specific configuration
+type ProvidersConfig struct {
+	AWS *AWSProviderConfig `json:"aws,omitempty"`
+}
+
+// AWSProviderConfig The tiers available for each resource type provisioned by the aws provider
+type AWSProviderConfig struct {
+	BlobStorage map[string]AWSStrategy `json:"blobstorage,omitempty"`
+}
+
+// AWSStrategy The aws configuration used to provision a resource in a tier
+type AWSStrategy struct {
+	Region string `json:"region,omitempty"`
+	// Strategy is passed to the aws api when creating the resource, e.g. s3.CreateBucketInput for blob storage
+	Strategy runtime.RawExtension `json:"strategy,omitempty"`
+	// Extends is the name of another tier in this config whose strategy this tier is merged onto
+	Extends string `json:"extends,omitempty"`
+	// Base tiers can only be extended, they can't be used by resources
+	Base bool `json:"base,omitempty"`
+	// RegionOverrides are merged onto the resolved strategy when it's used in the region they're keyed by
+	RegionOverrides map[string]runtime.RawExtension `json:"regionOverrides,omitempty"`
+	// Overridable are the fields of the strategy resources can override, nested fields are separated by dots
+	Overridable []string `json:"overridable,omitempty"`
+	// Endpoint is the url of an s3-compatible store to use instead of aws, e.g. ceph rgw or minio
+	Endpoint string `json:"endpoint,omitempty"`
+	// ForcePathStyle addresses buckets in the path of requests rather than the host
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// DisableSSL calls the endpoint over plain http
+	DisableSSL bool `json:"disableSSL,omitempty"`
+	// CABundle is a PEM encoded bundle of certificates trusted when calling the endpoint
+	CABundle string `json:"caBundle,omitempty"`
+	// CORSRules are set on buckets of the tier
+	CORSRules []CORSRule `json:"corsRules,omitempty"`
+	// PolicyStatements are set as the bucket policy of buckets of the tier, statements without a resource apply to the
+	// bucket and its objects
+	PolicyStatements []runtime.RawExtension `json:"policyStatements,omitempty"`
+	// AllowPublicPolicy allows policy statements granting access to any principal
+	AllowPublicPolicy *bool `json:"allowPublicPolicy,omitempty"`
+}
+
+// CloudResourceConfigSpec defines the desired state of CloudResourceConfig
+// +k8s:openapi-gen=true
+type CloudResourceConfigSpec struct {
+	// DeploymentTypes maps a deployment type, as requested in the type of a resource, to the strategies used for it
+	DeploymentTypes map[string]DeploymentStrategies `json:"deploymentTypes,omitempty"`
+	Providers       ProvidersConfig                 `json:"providers,omitempty"`
+}
+
+// CloudResourceConfigStatus defines the observed state of CloudResourceConfig
+// +k8s:openapi-gen=true
+type CloudResourceConfigStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Errors found when parsing the config, empty if the config is valid
+	Errors []string `json:"errors,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CloudResourceConfig is the Schema for the cloudresourceconfigs API
+// +k8s:openapi-gen=true
+// +genclient:nonNamespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+type CloudResourceConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CloudResourceConfigSpec   `json:"spec,omitempty"`
+	Status CloudResourceConfigStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CloudResourceConfigList contains a list of CloudResourceConfig
+type CloudResourceConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CloudResourceConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CloudResourceConfig{}, &CloudResourceConfigList{})
+}
@@ -0,0 +1,34 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType The type of a condition reported in the status of a resource
+type ConditionType string
+
+const (
+	// ConditionPaused Reconciliation of the resource is paused
+	ConditionPaused ConditionType = "Paused"
+	// ConditionDeletionBlocked The resource is protected from deletion, the cloud resource has not been removed
+	ConditionDeletionBlocked ConditionType = "DeletionBlocked"
+	// ConditionStrategyInvalid The tier of the resource is not defined or its strategy can't be used by the provider
+	ConditionStrategyInvalid ConditionType = "StrategyInvalid"
+	// ConditionOverridesRejected The overrides of the resource set fields its tier doesn't allow or make its strategy invalid
+	ConditionOverridesRejected ConditionType = "OverridesRejected"
+	// ConditionDrifted Settings of the cloud resource differ from its strategy and can't be corrected by the provider
+	ConditionDrifted ConditionType = "Drifted"
+	// ConditionDryRun Changes to the cloud resource are only planned, see the planned actions in the status
+	ConditionDryRun ConditionType = "DryRun"
+)
+
+// Condition Describes the state of a resource at a certain point
+// +k8s:openapi-gen=true
+type Condition struct {
+	Type               ConditionType          `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+}
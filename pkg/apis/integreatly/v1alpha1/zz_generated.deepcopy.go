@@ -8,13 +8,84 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSProviderConfig) DeepCopyInto(out *AWSProviderConfig) {
+	*out = *in
+	if in.BlobStorage != nil {
+		in, out := &in.BlobStorage, &out.BlobStorage
+		*out = make(map[string]AWSStrategy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSProviderConfig.
+func (in *AWSProviderConfig) DeepCopy() *AWSProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSStrategy) DeepCopyInto(out *AWSStrategy) {
+	*out = *in
+	in.Strategy.DeepCopyInto(&out.Strategy)
+	if in.RegionOverrides != nil {
+		in, out := &in.RegionOverrides, &out.RegionOverrides
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Overridable != nil {
+		in, out := &in.Overridable, &out.Overridable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CORSRules != nil {
+		in, out := &in.CORSRules, &out.CORSRules
+		*out = make([]CORSRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PolicyStatements != nil {
+		in, out := &in.PolicyStatements, &out.PolicyStatements
+		*out = make([]runtime.RawExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowPublicPolicy != nil {
+		in, out := &in.AllowPublicPolicy, &out.AllowPublicPolicy
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSStrategy.
+func (in *AWSStrategy) DeepCopy() *AWSStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BlobStorage) DeepCopyInto(out *BlobStorage) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -73,6 +144,25 @@ func (in *BlobStorageList) DeepCopyObject() runtime.Object {
 func (in *BlobStorageSpec) DeepCopyInto(out *BlobStorageSpec) {
 	*out = *in
 	out.SecretRef = in.SecretRef
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CORSRules != nil {
+		in, out := &in.CORSRules, &out.CORSRules
+		*out = make([]CORSRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PolicyStatements != nil {
+		in, out := &in.PolicyStatements, &out.PolicyStatements
+		*out = make([]runtime.RawExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -90,6 +180,23 @@ func (in *BlobStorageSpec) DeepCopy() *BlobStorageSpec {
 func (in *BlobStorageStatus) DeepCopyInto(out *BlobStorageStatus) {
 	*out = *in
 	out.SecretRef = in.SecretRef
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PlannedActions != nil {
+		in, out := &in.PlannedActions, &out.PlannedActions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManagedSettings != nil {
+		in, out := &in.ManagedSettings, &out.ManagedSettings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -103,6 +210,202 @@ func (in *BlobStorageStatus) DeepCopy() *BlobStorageStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CORSRule) DeepCopyInto(out *CORSRule) {
+	*out = *in
+	if in.AllowedOrigins != nil {
+		in, out := &in.AllowedOrigins, &out.AllowedOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedMethods != nil {
+		in, out := &in.AllowedMethods, &out.AllowedMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedHeaders != nil {
+		in, out := &in.AllowedHeaders, &out.AllowedHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExposeHeaders != nil {
+		in, out := &in.ExposeHeaders, &out.ExposeHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CORSRule.
+func (in *CORSRule) DeepCopy() *CORSRule {
+	if in == nil {
+		return nil
+	}
+	out := new(CORSRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudResourceConfig) DeepCopyInto(out *CloudResourceConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudResourceConfig.
+func (in *CloudResourceConfig) DeepCopy() *CloudResourceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudResourceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CloudResourceConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudResourceConfigList) DeepCopyInto(out *CloudResourceConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CloudResourceConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudResourceConfigList.
+func (in *CloudResourceConfigList) DeepCopy() *CloudResourceConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudResourceConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CloudResourceConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudResourceConfigSpec) DeepCopyInto(out *CloudResourceConfigSpec) {
+	*out = *in
+	if in.DeploymentTypes != nil {
+		in, out := &in.DeploymentTypes, &out.DeploymentTypes
+		*out = make(map[string]DeploymentStrategies, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Providers.DeepCopyInto(&out.Providers)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudResourceConfigSpec.
+func (in *CloudResourceConfigSpec) DeepCopy() *CloudResourceConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudResourceConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudResourceConfigStatus) DeepCopyInto(out *CloudResourceConfigStatus) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudResourceConfigStatus.
+func (in *CloudResourceConfigStatus) DeepCopy() *CloudResourceConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudResourceConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentStrategies) DeepCopyInto(out *DeploymentStrategies) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentStrategies.
+func (in *DeploymentStrategies) DeepCopy() *DeploymentStrategies {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentStrategies)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvidersConfig) DeepCopyInto(out *ProvidersConfig) {
+	*out = *in
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSProviderConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvidersConfig.
+func (in *ProvidersConfig) DeepCopy() *ProvidersConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvidersConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretRef) DeepCopyInto(out *SecretRef) {
 	*out = *in
@@ -11,9 +11,13 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"./pkg/apis/integreatly/v1alpha1.BlobStorage":       schema_pkg_apis_integreatly_v1alpha1_BlobStorage(ref),
-		"./pkg/apis/integreatly/v1alpha1.BlobStorageSpec":   schema_pkg_apis_integreatly_v1alpha1_BlobStorageSpec(ref),
-		"./pkg/apis/integreatly/v1alpha1.BlobStorageStatus": schema_pkg_apis_integreatly_v1alpha1_BlobStorageStatus(ref),
+		"./pkg/apis/integreatly/v1alpha1.BlobStorage":               schema_pkg_apis_integreatly_v1alpha1_BlobStorage(ref),
+		"./pkg/apis/integreatly/v1alpha1.BlobStorageSpec":           schema_pkg_apis_integreatly_v1alpha1_BlobStorageSpec(ref),
+		"./pkg/apis/integreatly/v1alpha1.BlobStorageStatus":         schema_pkg_apis_integreatly_v1alpha1_BlobStorageStatus(ref),
+		"./pkg/apis/integreatly/v1alpha1.CloudResourceConfig":       schema_pkg_apis_integreatly_v1alpha1_CloudResourceConfig(ref),
+		"./pkg/apis/integreatly/v1alpha1.CloudResourceConfigSpec":   schema_pkg_apis_integreatly_v1alpha1_CloudResourceConfigSpec(ref),
+		"./pkg/apis/integreatly/v1alpha1.CloudResourceConfigStatus": schema_pkg_apis_integreatly_v1alpha1_CloudResourceConfigStatus(ref),
+		"./pkg/apis/integreatly/v1alpha1.Condition":                 schema_pkg_apis_integreatly_v1alpha1_Condition(ref),
 	}
 }
 
@@ -84,12 +88,44 @@ func schema_pkg_apis_integreatly_v1alpha1_BlobStorageSpec(ref common.ReferenceCa
 							Ref: ref("./pkg/apis/integreatly/v1alpha1.SecretRef"),
 						},
 					},
+					"overrides": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Overrides are merged onto the strategy of the tier, only fields the tier declares overridable can be set",
+							Ref:         ref("k8s.io/apimachinery/pkg/runtime.RawExtension"),
+						},
+					},
+					"corsRules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CORSRules are added to the cors rules of the tier",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/integreatly/v1alpha1.CORSRule"),
+									},
+								},
+							},
+						},
+					},
+					"policyStatements": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PolicyStatements are added to the bucket policy statements of the tier, statements allowing any principal are rejected unless the tier allows public policies",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/runtime.RawExtension"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"type", "tier", "secretRef"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/integreatly/v1alpha1.SecretRef"},
+			"./pkg/apis/integreatly/v1alpha1.CORSRule", "./pkg/apis/integreatly/v1alpha1.SecretRef", "k8s.io/apimachinery/pkg/runtime.RawExtension"},
 	}
 }
 
@@ -117,10 +153,202 @@ func schema_pkg_apis_integreatly_v1alpha1_BlobStorageStatus(ref common.Reference
 							Ref: ref("./pkg/apis/integreatly/v1alpha1.SecretRef"),
 						},
 					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/integreatly/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"plannedActions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PlannedActions are the changes the operator would make to cloud resources, only set in dry-run mode",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"managedSettings": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ManagedSettings are the settings of the cloud resource managed by the operator, they're removed from the cloud resource when they're no longer set",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/integreatly/v1alpha1.Condition", "./pkg/apis/integreatly/v1alpha1.SecretRef"},
+	}
+}
+
+func schema_pkg_apis_integreatly_v1alpha1_CloudResourceConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CloudResourceConfig is the Schema for the cloudresourceconfigs API",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/integreatly/v1alpha1.CloudResourceConfigSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/integreatly/v1alpha1.CloudResourceConfigStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/integreatly/v1alpha1.CloudResourceConfigSpec", "./pkg/apis/integreatly/v1alpha1.CloudResourceConfigStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_integreatly_v1alpha1_CloudResourceConfigSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CloudResourceConfigSpec defines the desired state of CloudResourceConfig",
+				Properties: map[string]spec.Schema{
+					"deploymentTypes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeploymentTypes maps a deployment type, as requested in the type of a resource, to the strategies used for it",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/integreatly/v1alpha1.DeploymentStrategies"),
+									},
+								},
+							},
+						},
+					},
+					"providers": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/integreatly/v1alpha1.ProvidersConfig"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/integreatly/v1alpha1.DeploymentStrategies", "./pkg/apis/integreatly/v1alpha1.ProvidersConfig"},
+	}
+}
+
+func schema_pkg_apis_integreatly_v1alpha1_CloudResourceConfigStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CloudResourceConfigStatus defines the observed state of CloudResourceConfig",
+				Properties: map[string]spec.Schema{
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int64",
+						},
+					},
+					"errors": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Errors found when parsing the config, empty if the config is valid",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_integreatly_v1alpha1_Condition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Condition Describes the state of a resource at a certain point",
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
 				},
+				Required: []string{"type", "status"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/integreatly/v1alpha1.SecretRef"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
@@ -3,6 +3,7 @@ package blobstorage
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
@@ -11,15 +12,21 @@ import (
 
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/metrics"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 
 	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
 	errorUtil "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -29,19 +36,67 @@ import (
 
 var log = logf.Log.WithName("controller_blobstorage")
 
+const (
+	// finalizer used to remove secrets written outside the namespace of the instance
+	secretFinalizer = "finalizers.cloud-resources-operator.integreatly.org"
+
+	// instances requeued because of config changes that can be queued before the controller has started
+	configChangesBufferSize = 1024
+)
+
 // Add creates a new BlobStorage Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	r, err := newReconciler(mgr)
+	if err != nil {
+		return err
+	}
+	return add(mgr, r)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileBlobStorage{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager) (*ReconcileBlobStorage, error) {
+	// the manager's cache only holds objects in the watched namespace, secrets written to other namespaces are read
+	// from the api server
+	secretClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create secret client")
+	}
+	client := mgr.GetClient()
+	cfgCache, err := providers.GetConfigCache(mgr)
+	if err != nil {
+		return nil, err
+	}
+	cfgWatcher := &providers.ConfigWatcher{}
+	if err = cfgWatcher.Watch(cfgCache); err != nil {
+		return nil, err
+	}
+	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, providers.DefaultConfigNamespace, cfgCache)
+	cfgMgr.Watch(cfgWatcher)
+	awsCfgMgr := aws.NewDefaultConfigManager(cfgCache)
+	recorder := mgr.GetRecorder("blobstorage-controller")
+	awsCfgMgr.Recorder = recorder
+	awsCfgMgr.Watch(cfgWatcher)
+	awsProvider := aws.NewAWSBlobStorageProvider(client, awsCfgMgr)
+	awsProvider.Recorder = recorder
+
+	r := &ReconcileBlobStorage{
+		client:        client,
+		secretClient:  secretClient,
+		scheme:        mgr.GetScheme(),
+		recorder:      recorder,
+		cfgMgr:        cfgMgr,
+		providerList:  []providers.BlobStorageProvider{awsProvider},
+		configChanges: make(chan event.GenericEvent, configChangesBufferSize),
+	}
+	cfgMgr.Subscribe(r.requeueAffected)
+	awsCfgMgr.Subscribe(r.requeueAffected)
+	metrics.RegisterResourceLister(string(providers.BlobStorageResourceType), r.listResourceStates)
+	return r, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r *ReconcileBlobStorage) error {
 	// Create a new controller
 	c, err := controller.New("blobstorage-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -54,6 +109,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for instances affected by changes to the deployment type or tier they use
+	err = c.Watch(&source.Channel{Source: r.configChanges}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -65,20 +126,29 @@ type ReconcileBlobStorage struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
-	scheme *runtime.Scheme
+	// uncached client used for secrets written outside the namespace of the instance
+	secretClient client.Client
+	scheme       *runtime.Scheme
+	recorder     record.EventRecorder
+	cfgMgr       *providers.ConfigManager
+	// providers are kept between reconciles so they can cache their config
+	providerList []providers.BlobStorageProvider
+	// instances affected by config changes are sent here to be requeued
+	configChanges chan event.GenericEvent
 }
 
-func (r *ReconcileBlobStorage) Reconcile(request reconcile.Request) (reconcile.Result, error) {
-	providerList := []providers.BlobStorageProvider{aws.NewAWSBlobStorageProvider(r.client)}
-
+func (r *ReconcileBlobStorage) Reconcile(request reconcile.Request) (result reconcile.Result, err error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling BlobStorage")
 	ctx := context.TODO()
-	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, providers.DefaultConfigNamespace, r.client)
+	var providerName string
+	defer func(start time.Time) {
+		metrics.ObserveReconcile(string(providers.BlobStorageResourceType), providerName, start, err)
+	}(time.Now())
 
 	// Fetch the BlobStorage instance
 	instance := &integreatlyv1alpha1.BlobStorage{}
-	err := r.client.Get(ctx, request.NamespacedName, instance)
+	err = r.client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
@@ -89,44 +159,100 @@ func (r *ReconcileBlobStorage) Reconcile(request reconcile.Request) (reconcile.R
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	defer func() {
+		if err != nil {
+			r.recorder.Event(instance, corev1.EventTypeWarning, resources.EventReasonReconcileFailed, err.Error())
+		}
+	}()
+
+	// no changes are made to the cloud resource or secret while reconciliation is paused
+	if resources.IsPaused(&instance.ObjectMeta) {
+		reqLogger.Info("Reconciliation is paused, skipping")
+		msg := fmt.Sprintf("reconciliation is paused, remove annotation %s to resume", resources.AnnotationPaused)
+		if err = r.setCondition(ctx, instance, integreatlyv1alpha1.ConditionPaused, corev1.ConditionTrue, "PausedByAnnotation", msg); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+	instance.Status.Conditions = resources.RemoveCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionPaused)
 
-	stratMap, err := cfgMgr.GetStrategyMappingForDeploymentType(ctx, instance.Spec.Type)
+	stratMap, err := r.cfgMgr.GetStrategyMappingForDeploymentType(ctx, instance.Spec.Type)
 	if err != nil {
 		return reconcile.Result{}, errorUtil.Wrapf(err, "failed to read deployment type config for deployment %s", instance.Spec.Type)
 	}
 
-	for _, p := range providerList {
+	for _, p := range r.providerList {
 		if p.SupportsStrategy(stratMap.BlobStorage) {
+			providerName = p.GetName()
+			if instance.GetDeletionTimestamp() != nil && resources.IsDeletionProtected(&instance.ObjectMeta) {
+				reqLogger.Info("Deletion protection is enabled, cloud resource will not be removed")
+				msg := fmt.Sprintf("deletion protection is enabled, remove annotation %s to delete the cloud resource", resources.AnnotationDeletionProtection)
+				if resources.FindCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionDeletionBlocked) == nil {
+					r.recorder.Event(instance, corev1.EventTypeWarning, resources.EventReasonDeletionBlocked, msg)
+				}
+				if err = r.setCondition(ctx, instance, integreatlyv1alpha1.ConditionDeletionBlocked, corev1.ConditionTrue, "DeletionProtected", msg); err != nil {
+					return reconcile.Result{}, err
+				}
+				return reconcile.Result{}, nil
+			}
+
+			// no changes are made to the cloud resource or secret in dry-run mode, only planned. Instances being
+			// deleted keep their finalizers, so they're only removed once dry-run mode is turned off
+			if resources.IsDryRun(&instance.ObjectMeta) {
+				reqLogger.Info("Dry-run mode is enabled, planning changes")
+				return r.planStorage(ctx, instance, p)
+			}
+			instance.Status.Conditions = resources.RemoveCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionDryRun)
+			instance.Status.PlannedActions = nil
+
 			if instance.GetDeletionTimestamp() != nil {
+				r.recorder.Event(instance, corev1.EventTypeNormal, resources.EventReasonDeletionStarted, "removing the cloud resource and secret")
 				if err := p.DeleteStorage(ctx, r.client, instance); err != nil {
+					if condErr := r.reportStrategyError(ctx, instance, err); condErr != nil {
+						return reconcile.Result{}, condErr
+					}
 					return reconcile.Result{}, errorUtil.Wrapf(err, "failed to perform provider-specific storage deletion")
 				}
+				// the secret is kept until the cloud resource is gone so it can still be used if the deletion fails
+				if err := r.deleteSecret(ctx, instance); err != nil {
+					return reconcile.Result{}, errorUtil.Wrapf(err, "failed to remove secret for instance %s", instance.Name)
+				}
+				metrics.ResourceDrift.DeleteLabelValues(string(providers.BlobStorageResourceType), instance.Namespace, instance.Name)
+				r.recorder.Event(instance, corev1.EventTypeNormal, resources.EventReasonDeletionCompleted, "removed the cloud resource and secret")
 				return reconcile.Result{}, nil
 			}
 
 			bsi, err := p.CreateStorage(ctx, r.client, instance)
 			if err != nil {
+				if condErr := r.reportStrategyError(ctx, instance, err); condErr != nil {
+					return reconcile.Result{}, condErr
+				}
 				return reconcile.Result{}, err
 			}
+			// waiting for the cloud resource is expected, so it's requeued without an error and no warning is recorded
 			if bsi == nil {
-				return reconcile.Result{}, errorUtil.New("secret data is still reconciling")
+				reqLogger.Info("Secret data is still reconciling, requeueing")
+				return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 30}, nil
 			}
-			sec := &corev1.Secret{
-				ObjectMeta: controllerruntime.ObjectMeta{
-					Name:      instance.Spec.SecretRef.Name,
-					Namespace: instance.Namespace,
-				},
+			if err = r.reconcileSecret(ctx, instance, bsi.DeploymentDetails.Data()); err != nil {
+				return reconcile.Result{}, errorUtil.Wrapf(err, "failed to reconcile secret for instance %s", instance.Name)
 			}
-			controllerruntime.CreateOrUpdate(ctx, r.client, sec, func(existing runtime.Object) error {
-				e := existing.(*corev1.Secret)
-				if err = controllerutil.SetControllerReference(instance, e, r.scheme); err != nil {
-					return errorUtil.Wrapf(err, "failed to set owner on secret %s", sec.Name)
-				}
-				e.Data = bsi.DeploymentDetails.Data()
-				e.Type = corev1.SecretTypeOpaque
-				return nil
-			})
-			instance.Status.SecretRef = instance.Spec.SecretRef
+			instance.Status.SecretRef = integreatlyv1alpha1.SecretRef{
+				Name:      instance.Spec.SecretRef.Name,
+				Namespace: secretNamespace(instance),
+			}
+			instance.Status.Conditions = resources.RemoveCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionStrategyInvalid)
+			instance.Status.Conditions = resources.RemoveCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionOverridesRejected)
+			// the cloud resource is described once, the same drift is corrected and reported. Correcting is done
+			// without drift as well so the provider can record the settings it manages
+			drift, err := p.DescribeStorage(ctx, r.client, instance)
+			if err != nil {
+				return reconcile.Result{}, errorUtil.Wrapf(err, "failed to describe cloud resource for instance %s", instance.Name)
+			}
+			if drift, err = p.CorrectDrift(ctx, r.client, instance, drift); err != nil {
+				return reconcile.Result{}, errorUtil.Wrapf(err, "failed to correct drift of cloud resource for instance %s", instance.Name)
+			}
+			r.reportDrift(instance, drift)
 			instance.Status.Strategy = stratMap.BlobStorage
 			instance.Status.Provider = p.GetName()
 			if err = r.client.Status().Update(ctx, instance); err != nil {
@@ -137,3 +263,280 @@ func (r *ReconcileBlobStorage) Reconcile(request reconcile.Request) (reconcile.R
 	}
 	return reconcile.Result{}, errorUtil.New(fmt.Sprintf("unsupported deployment strategy %s", stratMap.BlobStorage))
 }
+
+// reconcileSecret Write the connection details of the instance to the secret referenced in its spec, secrets written
+// outside the namespace of the instance are tracked using labels and a finalizer as owner references can't be used
+func (r *ReconcileBlobStorage) reconcileSecret(ctx context.Context, instance *integreatlyv1alpha1.BlobStorage, data map[string][]byte) error {
+	secNs := secretNamespace(instance)
+	allowed, err := resources.IsSecretNamespaceAllowed(ctx, r.client, instance.Namespace, secNs)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to check if secrets can be written to namespace %s", secNs)
+	}
+	if !allowed {
+		return errorUtil.New(fmt.Sprintf("namespace %s does not accept secrets from namespace %s, it must be labelled %s=%s", secNs, instance.Namespace, resources.LabelSecretsFromNamespace, instance.Namespace))
+	}
+	if secNs != instance.Namespace && !resources.HasFinalizer(&instance.ObjectMeta, secretFinalizer) {
+		resources.AddFinalizer(&instance.ObjectMeta, secretFinalizer)
+		if err = r.client.Update(ctx, instance); err != nil {
+			return errorUtil.Wrapf(err, "failed to add finalizer to instance")
+		}
+	}
+
+	sec := &corev1.Secret{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      instance.Spec.SecretRef.Name,
+			Namespace: secNs,
+		},
+	}
+	op, err := controllerruntime.CreateOrUpdate(ctx, r.secretClientFor(instance, secNs), sec, func(existing runtime.Object) error {
+		e := existing.(*corev1.Secret)
+		if secNs == instance.Namespace {
+			if err := controllerutil.SetControllerReference(instance, e, r.scheme); err != nil {
+				return errorUtil.Wrapf(err, "failed to set owner on secret %s", sec.Name)
+			}
+		} else {
+			if e.ResourceVersion != "" && !resources.IsOwnedBy(e, instance.Name, instance.Namespace) {
+				return errorUtil.New(fmt.Sprintf("secret %s in namespace %s exists and was not created for this instance", e.Name, e.Namespace))
+			}
+			if e.Labels == nil {
+				e.Labels = map[string]string{}
+			}
+			for k, v := range resources.OwnerLabels(instance.Name, instance.Namespace) {
+				e.Labels[k] = v
+			}
+		}
+		e.Data = data
+		e.Type = corev1.SecretTypeOpaque
+		return nil
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update secret %s in namespace %s", sec.Name, sec.Namespace)
+	}
+	if op != controllerutil.OperationResultNone {
+		r.recorder.Event(instance, corev1.EventTypeNormal, resources.EventReasonSecretWritten, fmt.Sprintf("%s secret %s in namespace %s", op, sec.Name, sec.Namespace))
+	}
+
+	// the secret reference can change if the validating webhook isn't deployed, the secret written for the previous
+	// reference is removed so it isn't orphaned
+	prev := instance.Status.SecretRef
+	if prev.Name != "" && (prev.Name != sec.Name || prev.Namespace != sec.Namespace) {
+		if err = r.deleteSecretRef(ctx, instance, prev); err != nil {
+			return err
+		}
+		r.recorder.Event(instance, corev1.EventTypeNormal, resources.EventReasonSecretRemoved, fmt.Sprintf("removed secret %s in namespace %s of previous secret reference", prev.Name, prev.Namespace))
+	}
+	if secNs == instance.Namespace && resources.HasFinalizer(&instance.ObjectMeta, secretFinalizer) {
+		resources.RemoveFinalizer(&instance.ObjectMeta, secretFinalizer)
+		if err = r.client.Update(ctx, instance); err != nil {
+			return errorUtil.Wrapf(err, "failed to remove finalizer from instance")
+		}
+	}
+	return nil
+}
+
+// deleteSecret Remove the secret written for the instance when it's deleted if it is outside the namespace of the
+// instance, secrets in the same namespace are garbage collected through their owner reference. Secrets written for
+// previous secret references are removed when the reference changes so only the last written secret is removed
+func (r *ReconcileBlobStorage) deleteSecret(ctx context.Context, instance *integreatlyv1alpha1.BlobStorage) error {
+	if !resources.HasFinalizer(&instance.ObjectMeta, secretFinalizer) {
+		return nil
+	}
+	ref := instance.Status.SecretRef
+	if ref.Namespace != "" && ref.Namespace != instance.Namespace {
+		if err := r.deleteSecretRef(ctx, instance, ref); err != nil {
+			return err
+		}
+	}
+	resources.RemoveFinalizer(&instance.ObjectMeta, secretFinalizer)
+	if err := r.client.Update(ctx, instance); err != nil {
+		return errorUtil.Wrapf(err, "failed to remove finalizer from instance")
+	}
+	return nil
+}
+
+// deleteSecretRef Remove the secret referenced by ref if it was written for the instance, secrets that don't exist or
+// belong to something else are left alone
+func (r *ReconcileBlobStorage) deleteSecretRef(ctx context.Context, instance *integreatlyv1alpha1.BlobStorage, ref integreatlyv1alpha1.SecretRef) error {
+	ns := ref.Namespace
+	if ns == "" {
+		ns = instance.Namespace
+	}
+	c := r.secretClientFor(instance, ns)
+	sec := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ns}, sec)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get secret %s in namespace %s", ref.Name, ns)
+	}
+	owned := resources.IsOwnedBy(sec, instance.Name, instance.Namespace)
+	if ns == instance.Namespace {
+		owned = metav1.IsControlledBy(sec, instance)
+	}
+	if !owned {
+		return nil
+	}
+	if err = c.Delete(ctx, sec); err != nil && !errors.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete secret %s in namespace %s", ref.Name, ns)
+	}
+	return nil
+}
+
+// secretClientFor Get the client used for secrets of the instance in namespace ns, secrets in the namespace of the
+// instance are read from the manager's cache
+func (r *ReconcileBlobStorage) secretClientFor(instance *integreatlyv1alpha1.BlobStorage, ns string) client.Client {
+	if ns == instance.Namespace {
+		return r.client
+	}
+	return r.secretClient
+}
+
+// setCondition Set a condition on the instance, the status is only updated if the condition has changed
+func (r *ReconcileBlobStorage) setCondition(ctx context.Context, instance *integreatlyv1alpha1.BlobStorage, t integreatlyv1alpha1.ConditionType, status corev1.ConditionStatus, reason, message string) error {
+	c := resources.FindCondition(instance.Status.Conditions, t)
+	if c != nil && c.Status == status && c.Reason == reason && c.Message == message {
+		return nil
+	}
+	instance.Status.Conditions = resources.SetCondition(instance.Status.Conditions, t, status, reason, message)
+	if err := r.client.Status().Update(ctx, instance); err != nil {
+		return errorUtil.Wrapf(err, "failed to update status of instance %s in namespace %s", instance.Name, instance.Namespace)
+	}
+	return nil
+}
+
+// reportStrategyError Set a condition on the instance if err was caused by its tier not being defined, having an
+// invalid strategy or its overrides being rejected
+func (r *ReconcileBlobStorage) reportStrategyError(ctx context.Context, instance *integreatlyv1alpha1.BlobStorage, err error) error {
+	switch errorUtil.Cause(err) {
+	case providers.ErrTierNotFound:
+		return r.setCondition(ctx, instance, integreatlyv1alpha1.ConditionStrategyInvalid, corev1.ConditionTrue, "TierNotFound", err.Error())
+	case providers.ErrInvalidStrategy:
+		return r.setCondition(ctx, instance, integreatlyv1alpha1.ConditionStrategyInvalid, corev1.ConditionTrue, "InvalidStrategy", err.Error())
+	case providers.ErrInvalidOverrides:
+		return r.setCondition(ctx, instance, integreatlyv1alpha1.ConditionOverridesRejected, corev1.ConditionTrue, "InvalidOverrides", err.Error())
+	}
+	return nil
+}
+
+// planStorage Write the changes the provider would make for the instance to its status without making them
+func (r *ReconcileBlobStorage) planStorage(ctx context.Context, instance *integreatlyv1alpha1.BlobStorage, p providers.BlobStorageProvider) (reconcile.Result, error) {
+	actions, err := p.PlanStorage(ctx, r.client, instance)
+	if err != nil {
+		if condErr := r.reportStrategyError(ctx, instance, err); condErr != nil {
+			return reconcile.Result{}, condErr
+		}
+		return reconcile.Result{}, errorUtil.Wrapf(err, "failed to plan provider-specific storage")
+	}
+	if instance.GetDeletionTimestamp() == nil {
+		actions = append(actions, fmt.Sprintf("write secret %s in namespace %s", instance.Spec.SecretRef.Name, secretNamespace(instance)))
+	}
+	instance.Status.PlannedActions = actions
+	instance.Status.Conditions = resources.SetCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionDryRun, corev1.ConditionTrue, "DryRun", "changes to the cloud resource are only planned, see the planned actions in the status")
+	if err = r.client.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
+	}
+	return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 30}, nil
+}
+
+// reportDrift Set a condition and metric for drift between the cloud resource and its strategy that the provider
+// couldn't correct
+func (r *ReconcileBlobStorage) reportDrift(instance *integreatlyv1alpha1.BlobStorage, drift []providers.Drift) {
+	var unfixed []string
+	for _, d := range drift {
+		if d.Fixed {
+			continue
+		}
+		unfixed = append(unfixed, d.String())
+	}
+	metrics.ResourceDrift.WithLabelValues(string(providers.BlobStorageResourceType), instance.Namespace, instance.Name).Set(float64(len(unfixed)))
+	if len(unfixed) == 0 {
+		instance.Status.Conditions = resources.RemoveCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionDrifted)
+		return
+	}
+	msg := fmt.Sprintf("cloud resource settings differ from the strategy and can't be corrected: %s", strings.Join(unfixed, ", "))
+	instance.Status.Conditions = resources.SetCondition(instance.Status.Conditions, integreatlyv1alpha1.ConditionDrifted, corev1.ConditionTrue, "UnfixableDrift", msg)
+}
+
+// listResourceStates List the state of every instance for the managed resources metric
+func (r *ReconcileBlobStorage) listResourceStates() ([]metrics.ResourceState, error) {
+	list := &integreatlyv1alpha1.BlobStorageList{}
+	if err := r.client.List(context.TODO(), &client.ListOptions{}, list); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list instances")
+	}
+	var states []metrics.ResourceState
+	for i := range list.Items {
+		instance := &list.Items[i]
+		states = append(states, metrics.ResourceState{
+			Phase:    instancePhase(instance),
+			Tier:     instance.Spec.Tier,
+			Strategy: instance.Status.Strategy,
+		})
+	}
+	return states, nil
+}
+
+// instancePhase Describe how far an instance has got, instances are provisioning until the provider has created their
+// cloud resource for the first time
+func instancePhase(instance *integreatlyv1alpha1.BlobStorage) string {
+	conds := instance.Status.Conditions
+	switch {
+	case instance.GetDeletionTimestamp() != nil:
+		return "deleting"
+	case resources.IsPaused(&instance.ObjectMeta):
+		return "paused"
+	case resources.FindCondition(conds, integreatlyv1alpha1.ConditionDryRun) != nil:
+		return "dry-run"
+	case resources.FindCondition(conds, integreatlyv1alpha1.ConditionStrategyInvalid) != nil, resources.FindCondition(conds, integreatlyv1alpha1.ConditionOverridesRejected) != nil:
+		return "failed"
+	case instance.Status.Provider == "":
+		return "provisioning"
+	}
+	return "complete"
+}
+
+// secretNamespace Resolve the namespace the secret for the instance is written to
+func secretNamespace(instance *integreatlyv1alpha1.BlobStorage) string {
+	if instance.Spec.SecretRef.Namespace != "" {
+		return instance.Spec.SecretRef.Namespace
+	}
+	return instance.Namespace
+}
+
+// requeueAffected Requeue every instance using a deployment type or tier whose definition has changed
+func (r *ReconcileBlobStorage) requeueAffected(change providers.ConfigChange) {
+	list := &integreatlyv1alpha1.BlobStorageList{}
+	if err := r.client.List(context.TODO(), &client.ListOptions{}, list); err != nil {
+		log.Error(err, "failed to list instances affected by config change")
+		return
+	}
+	for i := range list.Items {
+		instance := &list.Items[i]
+		if !isAffected(instance, change) {
+			continue
+		}
+		// the subscriber is called from an informer handler so the send must not block, instances that can't be
+		// queued are reconciled with the new config on their periodic requeue
+		select {
+		case r.configChanges <- event.GenericEvent{Meta: instance, Object: instance}:
+			log.Info("Requeueing instance after config change", "Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+		default:
+			log.Info("Config change queue is full, instance will be reconciled on its next requeue", "Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+		}
+	}
+}
+
+// isAffected Check whether the deployment type or tier of an instance is part of a config change
+func isAffected(instance *integreatlyv1alpha1.BlobStorage, change providers.ConfigChange) bool {
+	for _, t := range change.DeploymentTypes {
+		if instance.Spec.Type == t {
+			return true
+		}
+	}
+	for _, tier := range change.Tiers[providers.BlobStorageResourceType] {
+		if instance.Spec.Tier == tier {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,444 @@
+//go:build integration
+// +build integration
+
+package blobstorage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis"
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	s3fake "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/fake"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	credsv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// The integration suite runs the controller against a local api server started by envtest, the kube-apiserver and
+// etcd binaries are looked up in KUBEBUILDER_ASSETS. Run it with `make test/integration`
+
+const (
+	testNamespace = "integration"
+	// secrets of instances in the test namespace can be written here, it isn't watched by the manager
+	testSecretNamespace = "integration-secrets"
+	testTier            = "development"
+	testTimeout         = time.Minute * 2
+)
+
+var (
+	testClient client.Client
+	testS3     *s3fake.S3
+)
+
+func TestMain(m *testing.M) {
+	env := &envtest.Environment{
+		CRDDirectoryPaths: []string{filepath.Join("..", "..", "..", "deploy", "crds")},
+		CRDs:              []*apiextensionsv1beta1.CustomResourceDefinition{credentialsRequestCRD()},
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		fmt.Println("failed to start test environment", err)
+		os.Exit(1)
+	}
+	stop := make(chan struct{})
+	if err = startManager(cfg, stop); err != nil {
+		fmt.Println("failed to start manager", err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	close(stop)
+	if err = env.Stop(); err != nil {
+		fmt.Println("failed to stop test environment", err)
+	}
+	os.Exit(code)
+}
+
+// startManager Start a manager watching the test namespace, as the operator is deployed, running the controller with
+// the aws provider calling the fake s3 backend, along with a fake cloud credential operator provisioning every
+// credentials request
+func startManager(cfg *rest.Config, stop chan struct{}) error {
+	mgr, err := manager.New(cfg, manager.Options{
+		Namespace:          testNamespace,
+		MetricsBindAddress: "0",
+	})
+	if err != nil {
+		return err
+	}
+	if err = apis.AddToScheme(mgr.GetScheme()); err != nil {
+		return err
+	}
+	if testClient, err = client.New(cfg, client.Options{Scheme: mgr.GetScheme()}); err != nil {
+		return err
+	}
+	if err = createConfig(testClient); err != nil {
+		return err
+	}
+
+	r, err := newReconciler(mgr)
+	if err != nil {
+		return err
+	}
+	testS3 = s3fake.NewS3("us-east-1")
+	for _, p := range r.providerList {
+		if awsProvider, ok := p.(*aws.AWSBlobStorageProvider); ok {
+			awsProvider.S3Client = func(stratCfg *aws.StrategyConfig, _ *aws.AWSCredentials) (s3iface.S3API, error) {
+				return testS3, nil
+			}
+		}
+	}
+	if err = add(mgr, r); err != nil {
+		return err
+	}
+	c, err := controller.New("fake-credential-operator", mgr, controller.Options{
+		Reconciler: &fakeCredentialOperator{client: mgr.GetClient()},
+	})
+	if err != nil {
+		return err
+	}
+	if err = c.Watch(&source.Kind{Type: &credsv1.CredentialsRequest{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := mgr.Start(stop); err != nil {
+			fmt.Println("manager exited", err)
+		}
+	}()
+	return nil
+}
+
+// createConfig Create the namespaces used by the tests and the config mapping the managed deployment type to a tier
+// of the aws provider
+func createConfig(c client.Client) error {
+	ctx := context.TODO()
+	objs := []runtime.Object{
+		&corev1.Namespace{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name: testNamespace,
+			},
+		},
+		&corev1.Namespace{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name: testSecretNamespace,
+				Labels: map[string]string{
+					resources.LabelSecretsFromNamespace: testNamespace,
+				},
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      providers.DefaultProviderConfigMapName,
+				Namespace: providers.DefaultConfigNamespace,
+			},
+			Data: map[string]string{
+				providers.ManagedDeploymentType: "{\"blobstorage\":\"aws\"}",
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      aws.DefaultConfigMapName,
+				Namespace: aws.DefaultConfigMapNamespace,
+			},
+			Data: map[string]string{
+				string(providers.BlobStorageResourceType): fmt.Sprintf("{\"%s\": {\"region\": \"eu-west-1\", \"strategy\": {\"ACL\": \"private\"}}}", testTier),
+			},
+		},
+	}
+	for _, obj := range objs {
+		if err := c.Create(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// credentialsRequestCRD A stub of the CredentialsRequest CRD installed by the cloud credential operator
+func credentialsRequestCRD() *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "credentialsrequests.cloudcredential.openshift.io",
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   credsv1.SchemeGroupVersion.Group,
+			Version: credsv1.SchemeGroupVersion.Version,
+			Scope:   apiextensionsv1beta1.NamespaceScoped,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Plural:   "credentialsrequests",
+				Singular: "credentialsrequest",
+				Kind:     "CredentialsRequest",
+				ListKind: "CredentialsRequestList",
+			},
+		},
+	}
+}
+
+// fakeCredentialOperator Provisions every credentials request by writing static credentials to its secret, as the
+// cloud credential operator would after creating them in aws
+type fakeCredentialOperator struct {
+	client client.Client
+}
+
+func (f *fakeCredentialOperator) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	cr := &credsv1.CredentialsRequest{}
+	if err := f.client.Get(ctx, request.NamespacedName, cr); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if cr.Status.Provisioned {
+		return reconcile.Result{}, nil
+	}
+	sec := &corev1.Secret{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      cr.Spec.SecretRef.Name,
+			Namespace: cr.Spec.SecretRef.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, f.client, sec, func(existing runtime.Object) error {
+		existing.(*corev1.Secret).Data = map[string][]byte{
+			"aws_access_key_id":     []byte("testkey"),
+			"aws_secret_access_key": []byte("testsecret"),
+		}
+		return nil
+	})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// the stub crd has no status subresource
+	cr.Status.Provisioned = true
+	return reconcile.Result{}, f.client.Update(ctx, cr)
+}
+
+// waitFor Poll the api server until condition is met or the test times out
+// updateInstance Apply update to the latest version of an instance, retrying on conflicts with the controller
+func updateInstance(t *testing.T, key types.NamespacedName, bs *integreatlyv1alpha1.BlobStorage, update func(bs *integreatlyv1alpha1.BlobStorage)) {
+	waitFor(t, "instance to be updated", func() (bool, error) {
+		if err := testClient.Get(context.TODO(), key, bs); err != nil {
+			return false, err
+		}
+		update(bs)
+		if err := testClient.Update(context.TODO(), bs); err != nil {
+			if errors.IsConflict(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+func waitFor(t *testing.T, msg string, condition func() (bool, error)) {
+	err := wait.PollImmediate(time.Second, testTimeout, condition)
+	if err != nil {
+		t.Fatalf("timed out waiting for %s: %v", msg, err)
+	}
+}
+
+func TestBlobStorageLifecycle(t *testing.T) {
+	ctx := context.TODO()
+	key := types.NamespacedName{Name: "lifecycle", Namespace: testNamespace}
+	bucket := fmt.Sprintf("%s-%s", key.Namespace, key.Name)
+	bs := &integreatlyv1alpha1.BlobStorage{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Spec: integreatlyv1alpha1.BlobStorageSpec{
+			Type: providers.ManagedDeploymentType,
+			Tier: testTier,
+			SecretRef: integreatlyv1alpha1.SecretRef{
+				Name: "lifecycle-sec",
+			},
+		},
+	}
+	if err := testClient.Create(ctx, bs); err != nil {
+		t.Fatal("failed to create instance", err)
+	}
+
+	t.Run("test bucket and secret are created", func(t *testing.T) {
+		waitFor(t, "instance to be provisioned", func() (bool, error) {
+			if err := testClient.Get(ctx, key, bs); err != nil {
+				return false, err
+			}
+			return bs.Status.Provider == providers.AWSDeploymentStrategy, nil
+		})
+		if testS3.Bucket(bucket) == nil {
+			t.Fatalf("expected bucket %s to be created", bucket)
+		}
+		sec := &corev1.Secret{}
+		if err := testClient.Get(ctx, types.NamespacedName{Name: "lifecycle-sec", Namespace: testNamespace}, sec); err != nil {
+			t.Fatal("failed to get secret", err)
+		}
+		if string(sec.Data["bucketName"]) != bucket {
+			t.Fatalf("unexpected bucket name, expected %s but got %s", bucket, string(sec.Data["bucketName"]))
+		}
+	})
+
+	// the validating webhook forbids changing the type, tier and secret reference, other spec fields can be updated
+	t.Run("test bucket is updated when the spec changes", func(t *testing.T) {
+		updateInstance(t, key, bs, func(bs *integreatlyv1alpha1.BlobStorage) {
+			bs.Spec.CORSRules = []integreatlyv1alpha1.CORSRule{{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}}}
+		})
+		// changes to the spec are watched, the bucket is updated without waiting for the periodic requeue
+		waitFor(t, "cors rules to be set on the bucket", func() (bool, error) {
+			b := testS3.Bucket(bucket)
+			return b != nil && len(b.CORSRules) == 1, nil
+		})
+	})
+
+	t.Run("test cors rules removed from the spec are removed from the bucket", func(t *testing.T) {
+		updateInstance(t, key, bs, func(bs *integreatlyv1alpha1.BlobStorage) {
+			bs.Spec.CORSRules = nil
+		})
+		waitFor(t, "cors rules to be removed from the bucket", func() (bool, error) {
+			b := testS3.Bucket(bucket)
+			return b != nil && len(b.CORSRules) == 0, nil
+		})
+	})
+
+	t.Run("test bucket is deleted with the instance", func(t *testing.T) {
+		if err := testClient.Delete(ctx, bs); err != nil {
+			t.Fatal("failed to delete instance", err)
+		}
+		waitFor(t, "instance to be removed", func() (bool, error) {
+			err := testClient.Get(ctx, key, &integreatlyv1alpha1.BlobStorage{})
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if testS3.Bucket(bucket) != nil {
+			t.Fatalf("expected bucket %s to be deleted", bucket)
+		}
+	})
+}
+
+func TestBlobStorageUndefinedTier(t *testing.T) {
+	ctx := context.TODO()
+	key := types.NamespacedName{Name: "undefined-tier", Namespace: testNamespace}
+	bs := &integreatlyv1alpha1.BlobStorage{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Spec: integreatlyv1alpha1.BlobStorageSpec{
+			Type: providers.ManagedDeploymentType,
+			Tier: "undefined",
+			SecretRef: integreatlyv1alpha1.SecretRef{
+				Name: "undefined-tier-sec",
+			},
+		},
+	}
+	if err := testClient.Create(ctx, bs); err != nil {
+		t.Fatal("failed to create instance", err)
+	}
+	waitFor(t, "strategy invalid condition", func() (bool, error) {
+		if err := testClient.Get(ctx, key, bs); err != nil {
+			return false, err
+		}
+		c := resources.FindCondition(bs.Status.Conditions, integreatlyv1alpha1.ConditionStrategyInvalid)
+		return c != nil && c.Reason == "TierNotFound", nil
+	})
+	if testS3.Bucket(fmt.Sprintf("%s-%s", key.Namespace, key.Name)) != nil {
+		t.Fatal("expected no bucket to be created for undefined tier")
+	}
+}
+
+func TestBlobStorageCrossNamespaceSecret(t *testing.T) {
+	ctx := context.TODO()
+	key := types.NamespacedName{Name: "cross-namespace", Namespace: testNamespace}
+	secKey := types.NamespacedName{Name: "cross-namespace-sec", Namespace: testSecretNamespace}
+	bs := &integreatlyv1alpha1.BlobStorage{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Spec: integreatlyv1alpha1.BlobStorageSpec{
+			Type: providers.ManagedDeploymentType,
+			Tier: testTier,
+			SecretRef: integreatlyv1alpha1.SecretRef{
+				Name:      secKey.Name,
+				Namespace: secKey.Namespace,
+			},
+		},
+	}
+	if err := testClient.Create(ctx, bs); err != nil {
+		t.Fatal("failed to create instance", err)
+	}
+
+	t.Run("test secret is written to a namespace the manager doesn't watch", func(t *testing.T) {
+		waitFor(t, "instance to be provisioned", func() (bool, error) {
+			if err := testClient.Get(ctx, key, bs); err != nil {
+				return false, err
+			}
+			return bs.Status.SecretRef.Namespace == secKey.Namespace, nil
+		})
+		sec := &corev1.Secret{}
+		if err := testClient.Get(ctx, secKey, sec); err != nil {
+			t.Fatal("failed to get secret", err)
+		}
+		if !resources.IsOwnedBy(sec, key.Name, key.Namespace) {
+			t.Fatalf("unexpected secret labels, expected owner labels for %s but got %v", key, sec.Labels)
+		}
+	})
+
+	t.Run("test secret is updated on later reconciles", func(t *testing.T) {
+		sec := &corev1.Secret{}
+		if err := testClient.Get(ctx, secKey, sec); err != nil {
+			t.Fatal("failed to get secret", err)
+		}
+		sec.Data = map[string][]byte{}
+		if err := testClient.Update(ctx, sec); err != nil {
+			t.Fatal("failed to update secret", err)
+		}
+		// changes to the secret aren't watched, it's written again when the instance is requeued
+		waitFor(t, "secret to be written again", func() (bool, error) {
+			if err := testClient.Get(ctx, secKey, sec); err != nil {
+				return false, err
+			}
+			return len(sec.Data) > 0, nil
+		})
+	})
+
+	t.Run("test secret is deleted with the instance", func(t *testing.T) {
+		if err := testClient.Delete(ctx, bs); err != nil {
+			t.Fatal("failed to delete instance", err)
+		}
+		waitFor(t, "instance to be removed", func() (bool, error) {
+			err := testClient.Get(ctx, key, &integreatlyv1alpha1.BlobStorage{})
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		err := testClient.Get(ctx, secKey, &corev1.Secret{})
+		if !errors.IsNotFound(err) {
+			t.Fatalf("expected secret %s to be deleted but got %v", secKey, err)
+		}
+	})
+}
@@ -0,0 +1,439 @@
+package blobstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testInstanceNamespace = "test"
+	testOtherNamespace    = "test-secrets"
+	testInstanceName      = "test"
+)
+
+// fakeDeploymentDetails Connection details returned by the fake provider
+type fakeDeploymentDetails struct{}
+
+func (d *fakeDeploymentDetails) Data() map[string][]byte {
+	return map[string][]byte{"bucketName": []byte("test")}
+}
+
+// fakeBlobStorageProvider Provider recording the calls made to it instead of managing a cloud resource
+type fakeBlobStorageProvider struct {
+	calls     []string
+	deleteErr error
+}
+
+var _ providers.BlobStorageProvider = &fakeBlobStorageProvider{}
+
+func (p *fakeBlobStorageProvider) GetName() string {
+	return "fake"
+}
+
+func (p *fakeBlobStorageProvider) SupportsStrategy(s string) bool {
+	return s == providers.AWSDeploymentStrategy
+}
+
+func (p *fakeBlobStorageProvider) TierExists(ctx context.Context, tier string) (bool, error) {
+	return true, nil
+}
+
+func (p *fakeBlobStorageProvider) CreateStorage(ctx context.Context, client client.Client, bs *integreatlyv1alpha1.BlobStorage) (*providers.BlobStorageInstance, error) {
+	p.calls = append(p.calls, "CreateStorage")
+	return &providers.BlobStorageInstance{DeploymentDetails: &fakeDeploymentDetails{}}, nil
+}
+
+func (p *fakeBlobStorageProvider) DeleteStorage(ctx context.Context, client client.Client, bs *integreatlyv1alpha1.BlobStorage) error {
+	p.calls = append(p.calls, "DeleteStorage")
+	return p.deleteErr
+}
+
+func (p *fakeBlobStorageProvider) PlanStorage(ctx context.Context, client client.Client, bs *integreatlyv1alpha1.BlobStorage) ([]string, error) {
+	p.calls = append(p.calls, "PlanStorage")
+	if bs.GetDeletionTimestamp() != nil {
+		return []string{"delete fake storage"}, nil
+	}
+	return []string{"create fake storage"}, nil
+}
+
+func (p *fakeBlobStorageProvider) DescribeStorage(ctx context.Context, client client.Client, bs *integreatlyv1alpha1.BlobStorage) ([]providers.Drift, error) {
+	p.calls = append(p.calls, "DescribeStorage")
+	return nil, nil
+}
+
+func (p *fakeBlobStorageProvider) CorrectDrift(ctx context.Context, client client.Client, bs *integreatlyv1alpha1.BlobStorage, drift []providers.Drift) ([]providers.Drift, error) {
+	p.calls = append(p.calls, "CorrectDrift")
+	return drift, nil
+}
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := integreatlyv1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+// buildTestReconciler Build a reconciler using p for the managed deployment type, objs are added to the fake client
+// along with the provider config and a namespace accepting secrets from the test namespace
+func buildTestReconciler(t *testing.T, p providers.BlobStorageProvider, objs ...runtime.Object) *ReconcileBlobStorage {
+	scheme := buildTestScheme(t)
+	objs = append(objs, &corev1.ConfigMap{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      providers.DefaultProviderConfigMapName,
+			Namespace: providers.DefaultConfigNamespace,
+		},
+		Data: map[string]string{
+			providers.ManagedDeploymentType: fmt.Sprintf(`{"blobstorage": "%s"}`, providers.AWSDeploymentStrategy),
+		},
+	}, &corev1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:   testOtherNamespace,
+			Labels: map[string]string{resources.LabelSecretsFromNamespace: testInstanceNamespace},
+		},
+	})
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+	return &ReconcileBlobStorage{
+		client:        c,
+		secretClient:  c,
+		scheme:        scheme,
+		recorder:      record.NewFakeRecorder(50),
+		cfgMgr:        providers.NewConfigManager(providers.DefaultProviderConfigMapName, providers.DefaultConfigNamespace, c),
+		providerList:  []providers.BlobStorageProvider{p},
+		configChanges: make(chan event.GenericEvent, configChangesBufferSize),
+	}
+}
+
+func buildTestInstance() *integreatlyv1alpha1.BlobStorage {
+	return &integreatlyv1alpha1.BlobStorage{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      testInstanceName,
+			Namespace: testInstanceNamespace,
+		},
+		Spec: integreatlyv1alpha1.BlobStorageSpec{
+			Type:      providers.ManagedDeploymentType,
+			Tier:      "development",
+			SecretRef: integreatlyv1alpha1.SecretRef{Name: "test-sec"},
+		},
+	}
+}
+
+// buildTestCrossNamespaceSecret Build a secret written for the test instance outside of its namespace
+func buildTestCrossNamespaceSecret(name, ns string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    resources.OwnerLabels(testInstanceName, testInstanceNamespace),
+		},
+	}
+}
+
+func reconcileTestInstance(r *ReconcileBlobStorage) (reconcile.Result, error) {
+	return r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testInstanceName, Namespace: testInstanceNamespace}})
+}
+
+func getTestInstance(t *testing.T, c client.Client) *integreatlyv1alpha1.BlobStorage {
+	instance := &integreatlyv1alpha1.BlobStorage{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: testInstanceName, Namespace: testInstanceNamespace}, instance); err != nil {
+		t.Fatal("failed to get instance", err)
+	}
+	return instance
+}
+
+func secretExists(t *testing.T, c client.Client, name, ns string) bool {
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns}, &corev1.Secret{})
+	if err != nil && !k8serr.IsNotFound(err) {
+		t.Fatal("failed to get secret", err)
+	}
+	return err == nil
+}
+
+func TestReconcileBlobStorage_requeueAffected(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	var objs []runtime.Object
+	for i := 0; i < 3; i++ {
+		objs = append(objs, &integreatlyv1alpha1.BlobStorage{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      fmt.Sprintf("test-%d", i),
+				Namespace: "test",
+			},
+			Spec: integreatlyv1alpha1.BlobStorageSpec{
+				Tier: "development",
+			},
+		})
+	}
+	r := &ReconcileBlobStorage{
+		client:        fake.NewFakeClientWithScheme(scheme, objs...),
+		configChanges: make(chan event.GenericEvent, 2),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.requeueAffected(providers.ConfigChange{
+			Tiers: map[providers.ResourceType][]string{providers.BlobStorageResourceType: {"development"}},
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected requeue not to block when the config change queue is full")
+	}
+	if len(r.configChanges) != 2 {
+		t.Fatalf("unexpected queued instances, expected 2 but got %d", len(r.configChanges))
+	}
+}
+
+func TestReconcileBlobStorage_deletion(t *testing.T) {
+	cases := []struct {
+		name          string
+		deleteErr     error
+		expectError   bool
+		expectSecret  bool
+		expectedCalls []string
+	}{
+		{
+			name:          "test secret is removed after the cloud resource is deleted",
+			expectedCalls: []string{"DeleteStorage"},
+		},
+		{
+			name:          "test secret is kept when the cloud resource deletion fails",
+			deleteErr:     errors.New("failed to delete bucket"),
+			expectError:   true,
+			expectSecret:  true,
+			expectedCalls: []string{"DeleteStorage"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := buildTestInstance()
+			now := metav1.Now()
+			instance.DeletionTimestamp = &now
+			instance.Finalizers = []string{secretFinalizer}
+			instance.Spec.SecretRef.Namespace = testOtherNamespace
+			instance.Status.SecretRef = integreatlyv1alpha1.SecretRef{Name: "test-sec", Namespace: testOtherNamespace}
+			p := &fakeBlobStorageProvider{deleteErr: tc.deleteErr}
+			r := buildTestReconciler(t, p, instance, buildTestCrossNamespaceSecret("test-sec", testOtherNamespace))
+
+			_, err := reconcileTestInstance(r)
+			if tc.expectError && err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if fmt.Sprint(p.calls) != fmt.Sprint(tc.expectedCalls) {
+				t.Fatalf("unexpected provider calls, expected %v but got %v", tc.expectedCalls, p.calls)
+			}
+			if exists := secretExists(t, r.client, "test-sec", testOtherNamespace); exists != tc.expectSecret {
+				t.Fatalf("unexpected secret, expected secret to exist %t but got %t", tc.expectSecret, exists)
+			}
+			if hasFinalizer := resources.HasFinalizer(&getTestInstance(t, r.client).ObjectMeta, secretFinalizer); hasFinalizer != tc.expectSecret {
+				t.Fatalf("unexpected finalizer, expected finalizer to be set %t but got %t", tc.expectSecret, hasFinalizer)
+			}
+		})
+	}
+}
+
+func TestReconcileBlobStorage_secretRefChange(t *testing.T) {
+	cases := []struct {
+		name              string
+		specNamespace     string
+		previousNamespace string
+		expectFinalizer   bool
+	}{
+		{
+			name:              "test secret in the previous namespace is removed when the reference moves to the instance namespace",
+			previousNamespace: testOtherNamespace,
+		},
+		{
+			name:            "test secret in the instance namespace is removed when the reference moves to another namespace",
+			specNamespace:   testOtherNamespace,
+			expectFinalizer: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := buildTestInstance()
+			instance.Spec.SecretRef.Namespace = tc.specNamespace
+			instance.Status.SecretRef = integreatlyv1alpha1.SecretRef{Name: "test-old-sec", Namespace: tc.previousNamespace}
+			previousNamespace := tc.previousNamespace
+			if previousNamespace == "" {
+				previousNamespace = testInstanceNamespace
+			}
+			prevSec := buildTestCrossNamespaceSecret("test-old-sec", previousNamespace)
+			if previousNamespace == testInstanceNamespace {
+				prevSec.Labels = nil
+				prevSec.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(instance, integreatlyv1alpha1.SchemeGroupVersion.WithKind("BlobStorage"))}
+			} else {
+				instance.Finalizers = []string{secretFinalizer}
+			}
+			r := buildTestReconciler(t, &fakeBlobStorageProvider{}, instance, prevSec)
+
+			if _, err := reconcileTestInstance(r); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if secretExists(t, r.client, "test-old-sec", previousNamespace) {
+				t.Fatalf("unexpected secret, expected secret of the previous reference in namespace %s to be removed", previousNamespace)
+			}
+			newNamespace := secretNamespace(instance)
+			if !secretExists(t, r.client, "test-sec", newNamespace) {
+				t.Fatalf("expected secret to be written to namespace %s", newNamespace)
+			}
+			updated := getTestInstance(t, r.client)
+			if updated.Status.SecretRef.Name != "test-sec" || updated.Status.SecretRef.Namespace != newNamespace {
+				t.Fatalf("unexpected secret reference, expected test-sec in namespace %s but got %v", newNamespace, updated.Status.SecretRef)
+			}
+			if hasFinalizer := resources.HasFinalizer(&updated.ObjectMeta, secretFinalizer); hasFinalizer != tc.expectFinalizer {
+				t.Fatalf("unexpected finalizer, expected finalizer to be set %t but got %t", tc.expectFinalizer, hasFinalizer)
+			}
+		})
+	}
+}
+
+func TestReconcileBlobStorage_paused(t *testing.T) {
+	instance := buildTestInstance()
+	instance.Annotations = map[string]string{resources.AnnotationPaused: "true"}
+	p := &fakeBlobStorageProvider{}
+	r := buildTestReconciler(t, p, instance)
+
+	if _, err := reconcileTestInstance(r); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(p.calls) != 0 {
+		t.Fatalf("unexpected provider calls, expected none but got %v", p.calls)
+	}
+	if secretExists(t, r.client, "test-sec", testInstanceNamespace) {
+		t.Fatal("unexpected secret, expected no secret to be written while paused")
+	}
+	if resources.FindCondition(getTestInstance(t, r.client).Status.Conditions, integreatlyv1alpha1.ConditionPaused) == nil {
+		t.Fatalf("expected condition %s to be set", integreatlyv1alpha1.ConditionPaused)
+	}
+}
+
+func TestReconcileBlobStorage_deletionProtection(t *testing.T) {
+	instance := buildTestInstance()
+	now := metav1.Now()
+	instance.DeletionTimestamp = &now
+	instance.Finalizers = []string{secretFinalizer}
+	instance.Annotations = map[string]string{resources.AnnotationDeletionProtection: "true"}
+	instance.Spec.SecretRef.Namespace = testOtherNamespace
+	instance.Status.SecretRef = integreatlyv1alpha1.SecretRef{Name: "test-sec", Namespace: testOtherNamespace}
+	p := &fakeBlobStorageProvider{}
+	r := buildTestReconciler(t, p, instance, buildTestCrossNamespaceSecret("test-sec", testOtherNamespace))
+
+	if _, err := reconcileTestInstance(r); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(p.calls) != 0 {
+		t.Fatalf("unexpected provider calls, expected none but got %v", p.calls)
+	}
+	protected := getTestInstance(t, r.client)
+	if !resources.HasFinalizer(&protected.ObjectMeta, secretFinalizer) {
+		t.Fatal("expected finalizer to be kept while deletion protection is enabled")
+	}
+	if !secretExists(t, r.client, "test-sec", testOtherNamespace) {
+		t.Fatal("expected secret to be kept while deletion protection is enabled")
+	}
+	if resources.FindCondition(protected.Status.Conditions, integreatlyv1alpha1.ConditionDeletionBlocked) == nil {
+		t.Fatalf("expected condition %s to be set", integreatlyv1alpha1.ConditionDeletionBlocked)
+	}
+
+	// removing the annotation lets the deletion proceed
+	protected.Annotations = nil
+	if err := r.client.Update(context.TODO(), protected); err != nil {
+		t.Fatal("failed to remove deletion protection annotation", err)
+	}
+	if _, err := reconcileTestInstance(r); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if fmt.Sprint(p.calls) != "[DeleteStorage]" {
+		t.Fatalf("unexpected provider calls, expected [DeleteStorage] but got %v", p.calls)
+	}
+	if resources.HasFinalizer(&getTestInstance(t, r.client).ObjectMeta, secretFinalizer) {
+		t.Fatal("expected finalizer to be removed once deletion protection is disabled")
+	}
+	if secretExists(t, r.client, "test-sec", testOtherNamespace) {
+		t.Fatal("expected secret to be removed once deletion protection is disabled")
+	}
+}
+
+func TestReconcileBlobStorage_dryRun(t *testing.T) {
+	cases := []struct {
+		name            string
+		deleting        bool
+		expectedActions []string
+	}{
+		{
+			name:            "test changes are planned without being made",
+			expectedActions: []string{"create fake storage", "write secret test-sec in namespace test-secrets"},
+		},
+		{
+			name:            "test deletion is planned and the instance is kept",
+			deleting:        true,
+			expectedActions: []string{"delete fake storage"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := buildTestInstance()
+			instance.Annotations = map[string]string{resources.AnnotationDryRun: "true"}
+			instance.Spec.SecretRef.Namespace = testOtherNamespace
+			objs := []runtime.Object{instance}
+			if tc.deleting {
+				now := metav1.Now()
+				instance.DeletionTimestamp = &now
+				instance.Finalizers = []string{secretFinalizer}
+				instance.Status.SecretRef = integreatlyv1alpha1.SecretRef{Name: "test-sec", Namespace: testOtherNamespace}
+				objs = append(objs, buildTestCrossNamespaceSecret("test-sec", testOtherNamespace))
+			}
+			p := &fakeBlobStorageProvider{}
+			r := buildTestReconciler(t, p, objs...)
+
+			if _, err := reconcileTestInstance(r); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if fmt.Sprint(p.calls) != "[PlanStorage]" {
+				t.Fatalf("unexpected provider calls, expected [PlanStorage] but got %v", p.calls)
+			}
+			planned := getTestInstance(t, r.client)
+			if fmt.Sprint(planned.Status.PlannedActions) != fmt.Sprint(tc.expectedActions) {
+				t.Fatalf("unexpected planned actions, expected %v but got %v", tc.expectedActions, planned.Status.PlannedActions)
+			}
+			if resources.FindCondition(planned.Status.Conditions, integreatlyv1alpha1.ConditionDryRun) == nil {
+				t.Fatalf("expected condition %s to be set", integreatlyv1alpha1.ConditionDryRun)
+			}
+			if secretExists(t, r.client, "test-sec", testOtherNamespace) != tc.deleting {
+				t.Fatalf("unexpected secret, expected secret to exist %t", tc.deleting)
+			}
+			if resources.HasFinalizer(&planned.ObjectMeta, secretFinalizer) != tc.deleting {
+				t.Fatalf("unexpected finalizer, expected finalizer to be set %t", tc.deleting)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package cloudresourceconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_cloudresourceconfig")
+
+// Add creates a new CloudResourceConfig Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileCloudResourceConfig{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// the CloudResourceConfig CRD is optional, config is only read from the legacy configmaps when it isn't installed
+	gvk := integreatlyv1alpha1.SchemeGroupVersion.WithKind("CloudResourceConfig")
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("CloudResourceConfig kind is not installed, the controller will not be started")
+			return nil
+		}
+		return errorUtil.Wrap(err, "failed to get rest mapping for CloudResourceConfig")
+	}
+
+	// Create a new controller
+	c, err := controller.New("cloudresourceconfig-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CloudResourceConfig
+	err = c.Watch(&source.Kind{Type: &integreatlyv1alpha1.CloudResourceConfig{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileCloudResourceConfig implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileCloudResourceConfig{}
+
+// ReconcileCloudResourceConfig reconciles a CloudResourceConfig object, reporting errors found in the config in its
+// status
+type ReconcileCloudResourceConfig struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func (r *ReconcileCloudResourceConfig) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("Reconciling CloudResourceConfig")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.CloudResourceConfig{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	status := integreatlyv1alpha1.CloudResourceConfigStatus{
+		ObservedGeneration: instance.Generation,
+		Errors:             validateConfig(&instance.Spec),
+	}
+	if len(status.Errors) > 0 {
+		reqLogger.Info("Config is invalid", "Errors", status.Errors)
+	}
+	if reflect.DeepEqual(status, instance.Status) {
+		return reconcile.Result{}, nil
+	}
+	instance.Status = status
+	if err = r.client.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, errorUtil.Wrapf(err, "failed to update status of cloud resource config %s", instance.Name)
+	}
+	return reconcile.Result{}, nil
+}
+
+// validateConfig Check every tier in the config can be parsed by its provider, returning a sorted list of errors
+func validateConfig(spec *integreatlyv1alpha1.CloudResourceConfigSpec) []string {
+	var errs []string
+	for dt, ds := range spec.DeploymentTypes {
+		if ds.BlobStorage == "" {
+			errs = append(errs, fmt.Sprintf("deploymentTypes.%s.blobstorage: strategy must be specified", dt))
+		}
+	}
+	if spec.Providers.AWS != nil {
+		tiers := map[string]*aws.StrategyConfig{}
+		for tier, s := range spec.Providers.AWS.BlobStorage {
+			tiers[tier] = aws.NewStrategyConfig(s)
+		}
+		_, invalid := aws.ResolveStrategies(tiers, aws.ValidateBlobStorageStrategy)
+		for tier, err := range invalid {
+			errs = append(errs, fmt.Sprintf("providers.aws.blobstorage.%s: %s", tier, err.Error()))
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
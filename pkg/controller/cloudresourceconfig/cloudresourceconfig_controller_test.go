@@ -0,0 +1,130 @@
+package cloudresourceconfig
+
+import (
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// fakeManager Manager without any kinds installed, recording the runnables added to it
+type fakeManager struct {
+	manager.Manager
+	runnables []manager.Runnable
+}
+
+func (m *fakeManager) Add(r manager.Runnable) error {
+	m.runnables = append(m.runnables, r)
+	return nil
+}
+
+func (m *fakeManager) GetRESTMapper() meta.RESTMapper {
+	return meta.NewDefaultRESTMapper(nil)
+}
+
+func TestAdd_crdNotInstalled(t *testing.T) {
+	mgr := &fakeManager{}
+	if err := add(mgr, &ReconcileCloudResourceConfig{}); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(mgr.runnables) != 0 {
+		t.Fatalf("unexpected runnables, expected no controller to be added but got %d", len(mgr.runnables))
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name           string
+		spec           *integreatlyv1alpha1.CloudResourceConfigSpec
+		expectedErrors int
+	}{
+		{
+			name: "test valid config has no errors",
+			spec: &integreatlyv1alpha1.CloudResourceConfigSpec{
+				DeploymentTypes: map[string]integreatlyv1alpha1.DeploymentStrategies{
+					"managed": {BlobStorage: "aws"},
+				},
+				Providers: integreatlyv1alpha1.ProvidersConfig{
+					AWS: &integreatlyv1alpha1.AWSProviderConfig{
+						BlobStorage: map[string]integreatlyv1alpha1.AWSStrategy{
+							"development": {
+								Region:   "eu-west-1",
+								Strategy: runtime.RawExtension{Raw: []byte("{\"ACL\":\"private\"}")},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "test deployment type without strategy is reported",
+			spec: &integreatlyv1alpha1.CloudResourceConfigSpec{
+				DeploymentTypes: map[string]integreatlyv1alpha1.DeploymentStrategies{
+					"managed": {},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "test strategy that can't be parsed is reported",
+			spec: &integreatlyv1alpha1.CloudResourceConfigSpec{
+				Providers: integreatlyv1alpha1.ProvidersConfig{
+					AWS: &integreatlyv1alpha1.AWSProviderConfig{
+						BlobStorage: map[string]integreatlyv1alpha1.AWSStrategy{
+							"development": {
+								Strategy: runtime.RawExtension{Raw: []byte("{\"ACL\":true}")},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "test tier extending undefined tier is reported",
+			spec: &integreatlyv1alpha1.CloudResourceConfigSpec{
+				Providers: integreatlyv1alpha1.ProvidersConfig{
+					AWS: &integreatlyv1alpha1.AWSProviderConfig{
+						BlobStorage: map[string]integreatlyv1alpha1.AWSStrategy{
+							"development": {
+								Extends: "missing",
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "test base tier is only validated through the tiers extending it",
+			spec: &integreatlyv1alpha1.CloudResourceConfigSpec{
+				Providers: integreatlyv1alpha1.ProvidersConfig{
+					AWS: &integreatlyv1alpha1.AWSProviderConfig{
+						BlobStorage: map[string]integreatlyv1alpha1.AWSStrategy{
+							"base": {
+								Base:     true,
+								Strategy: runtime.RawExtension{Raw: []byte("{\"ACL\":\"private\"}")},
+							},
+							"development": {
+								Extends:  "base",
+								Strategy: runtime.RawExtension{Raw: []byte("{\"ObjectLockEnabledForBucket\":true}")},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: 0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateConfig(tc.spec)
+			if len(errs) != tc.expectedErrors {
+				t.Fatalf("unexpected number of errors, expected %d but got %d: %v", tc.expectedErrors, len(errs), errs)
+			}
+		})
+	}
+}
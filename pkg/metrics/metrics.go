@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+const namespace = "cloud_resource_operator"
+
+var log = logf.Log.WithName("metrics")
+
+var (
+	// ReconcileDuration The time taken to reconcile a resource
+	ReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Time taken to reconcile a resource",
+	}, []string{"resource_type", "provider"})
+
+	// ReconcileErrors The number of reconciles that returned an error
+	ReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_errors_total",
+		Help:      "Number of reconciles of a resource that returned an error",
+	}, []string{"resource_type", "provider"})
+
+	// AWSAPICalls The number of calls made to aws apis, code is the aws error code or empty if the call succeeded
+	AWSAPICalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "aws_api_calls_total",
+		Help:      "Number of calls made to aws apis, code is the aws error code or empty if the call succeeded",
+	}, []string{"service", "operation", "code"})
+
+	// AWSAPILatency The time taken by calls to aws apis, including retries
+	AWSAPILatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "aws_api_latency_seconds",
+		Help:      "Time taken by calls to aws apis, including retries",
+	}, []string{"service", "operation"})
+
+	// CredentialProvisioningWait The time spent waiting for a credentials request to be provisioned
+	CredentialProvisioningWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "credential_provisioning_wait_seconds",
+		Help:      "Time spent waiting for a credentials request to be provisioned",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300},
+	}, []string{"provider"})
+
+	// ResourceDrift The number of settings of a cloud resource that differ from its strategy and couldn't be corrected
+	ResourceDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "resource_drift",
+		Help:      "Number of settings of a cloud resource that differ from its strategy and could not be corrected",
+	}, []string{"resource_type", "namespace", "name"})
+
+	resourcesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "resources"),
+		"Number of managed resources by phase, tier and strategy",
+		[]string{"resource_type", "phase", "tier", "strategy"}, nil,
+	)
+)
+
+// ResourceState The labels a managed resource is counted by
+type ResourceState struct {
+	Phase    string
+	Tier     string
+	Strategy string
+}
+
+// ResourceLister Lists the state of every managed resource of a type, called whenever metrics are collected
+type ResourceLister func() ([]ResourceState, error)
+
+// resourceCollector Counts the managed resources of every registered type when metrics are collected, so resources
+// that no longer exist or have changed are never reported
+type resourceCollector struct {
+	mu      sync.Mutex
+	listers map[string]ResourceLister
+}
+
+var resources = &resourceCollector{
+	listers: map[string]ResourceLister{},
+}
+
+// RegisterResourceLister Count the resources of a type listed by l in the managed resources gauge
+func RegisterResourceLister(resourceType string, l ResourceLister) {
+	resources.mu.Lock()
+	defer resources.mu.Unlock()
+	resources.listers[resourceType] = l
+}
+
+func (c *resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourcesDesc
+}
+
+func (c *resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for resourceType, l := range c.listers {
+		states, err := l()
+		if err != nil {
+			log.Error(err, "failed to list resources for metrics", "ResourceType", resourceType)
+			continue
+		}
+		counts := map[ResourceState]int{}
+		for _, s := range states {
+			counts[s]++
+		}
+		for s, count := range counts {
+			ch <- prometheus.MustNewConstMetric(resourcesDesc, prometheus.GaugeValue, float64(count), resourceType, s.Phase, s.Tier, s.Strategy)
+		}
+	}
+}
+
+// ObserveReconcile Record the duration of a reconcile that started at start and whether it returned an error
+func ObserveReconcile(resourceType, provider string, start time.Time, err error) {
+	ReconcileDuration.WithLabelValues(resourceType, provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ReconcileErrors.WithLabelValues(resourceType, provider).Inc()
+	}
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		ReconcileDuration,
+		ReconcileErrors,
+		AWSAPICalls,
+		AWSAPILatency,
+		CredentialProvisioningWait,
+		ResourceDrift,
+		resources,
+	)
+}
@@ -2,25 +2,25 @@ package aws
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	v1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
-	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 
 	"github.com/aws/aws-sdk-go/aws"
 
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
@@ -58,13 +58,23 @@ type AWSBlobStorageProvider struct {
 	Client            client.Client
 	CredentialManager *CredentialManager
 	ConfigManager     *ConfigManager
+	// S3Client creates the clients used to call s3
+	S3Client S3ClientFactory
+	// Recorder is used to report progress on the instance being reconciled, no events are recorded if nil
+	Recorder record.EventRecorder
 }
 
-func NewAWSBlobStorageProvider(client client.Client) *AWSBlobStorageProvider {
+// NewAWSBlobStorageProvider Create a provider reading its strategies using cfgMgr, a config manager reading from client
+// is used if it's nil
+func NewAWSBlobStorageProvider(client client.Client, cfgMgr *ConfigManager) *AWSBlobStorageProvider {
+	if cfgMgr == nil {
+		cfgMgr = NewDefaultConfigManager(client)
+	}
 	return &AWSBlobStorageProvider{
 		Client:            client,
 		CredentialManager: NewCredentialManager(client),
-		ConfigManager:     NewDefaultConfigManager(client),
+		ConfigManager:     cfgMgr,
+		S3Client:          newS3Client,
 	}
 }
 
@@ -76,6 +86,18 @@ func (p *AWSBlobStorageProvider) SupportsStrategy(d string) bool {
 	return d == providers.AWSDeploymentStrategy
 }
 
+// TierExists Check whether a strategy is defined for the tier in the aws strategy config
+func (p *AWSBlobStorageProvider) TierExists(ctx context.Context, tier string) (bool, error) {
+	_, err := p.ConfigManager.ReadBlobStorageStrategy(ctx, tier)
+	if err != nil {
+		if errorUtil.Cause(err) == providers.ErrTierNotFound {
+			return false, nil
+		}
+		return false, errorUtil.Wrap(err, "failed to read aws strategy config")
+	}
+	return true, nil
+}
+
 // CreateStorage Create S3 bucket from strategy config and credentials to interact with it
 func (p *AWSBlobStorageProvider) CreateStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) (*providers.BlobStorageInstance, error) {
 	// handle provider-specific finalizer
@@ -97,23 +119,32 @@ func (p *AWSBlobStorageProvider) CreateStorage(ctx context.Context, client clien
 
 	// create the credentials to be used by the end-user, whoever created the blobstorage instance
 	endUserCredsName := fmt.Sprintf("cloud-resources-aws-s3-%s-credentials", bs.Name)
+	endUserCredsReq, err := p.CredentialManager.GetCredentialsRequest(ctx, endUserCredsName, bs.Namespace)
+	if err != nil {
+		return nil, err
+	}
 	endUserCreds, _, err := p.CredentialManager.ReoncileBucketOwnerCredentials(ctx, endUserCredsName, bs.Namespace, *bucketCreateCfg.Bucket)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to reconcile s3 put object credentials")
 	}
+	p.recordCredentialEvents(bs, endUserCredsName, endUserCredsReq)
 
 	// create the credentials to be used by the aws resource providers, not to be used by end-user
+	providerCredsReq, err := p.CredentialManager.GetCredentialsRequest(ctx, p.CredentialManager.ProviderCredentialName, bs.Namespace)
+	if err != nil {
+		return nil, err
+	}
 	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, bs.Namespace)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to reconcile aws blob storage provider credentials")
 	}
+	p.recordCredentialEvents(bs, p.CredentialManager.ProviderCredentialName, providerCredsReq)
 
-	// setup aws s3 sdk session
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region:      aws.String(stratCfg.Region),
-		Credentials: credentials.NewStaticCredentials(providerCreds.AccessKeyID, providerCreds.SecretAccessKey, ""),
-	}))
-	s3svc := s3.New(sess)
+	// setup aws s3 sdk client
+	s3svc, err := p.S3Client(stratCfg, providerCreds)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create s3 client")
+	}
 
 	// the aws access key can sometimes still not be registered in aws on first try, so loop
 	var existingBuckets []*s3.Bucket
@@ -146,16 +177,42 @@ func (p *AWSBlobStorageProvider) CreateStorage(ctx context.Context, client clien
 			break
 		}
 	}
+	// drift of existing buckets is described and corrected by DescribeStorage and CorrectDrift
 	if foundBucket != nil {
+		// the instance has never been reconciled successfully, so the bucket existed before it
+		if bs.Status.Provider == "" {
+			p.recordEvent(bs, corev1.EventTypeNormal, resources.EventReasonResourceAdopted, fmt.Sprintf("using existing s3 bucket %s", *bucketCreateCfg.Bucket))
+		}
 		return bsi, nil
 	}
 	_, err = s3svc.CreateBucket(bucketCreateCfg)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to create s3 bucket")
 	}
+	if err = applyBucketPolicies(s3svc, *bucketCreateCfg.Bucket, stratCfg); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to set cors rules and policy of s3 bucket %s", *bucketCreateCfg.Bucket)
+	}
+	p.recordEvent(bs, corev1.EventTypeNormal, resources.EventReasonResourceCreated, fmt.Sprintf("created s3 bucket %s in region %s", *bucketCreateCfg.Bucket, stratCfg.Region))
 	return bsi, nil
 }
 
+// recordCredentialEvents Record events for a credential request that has been reconciled, before is the request as it
+// was before it was reconciled or nil if it didn't exist
+func (p *AWSBlobStorageProvider) recordCredentialEvents(bs *v1alpha1.BlobStorage, name string, before *v1.CredentialsRequest) {
+	if before == nil {
+		p.recordEvent(bs, corev1.EventTypeNormal, resources.EventReasonCredentialsRequestCreated, fmt.Sprintf("created credentials request %s", name))
+	}
+	if before == nil || !before.Status.Provisioned {
+		p.recordEvent(bs, corev1.EventTypeNormal, resources.EventReasonCredentialsReady, fmt.Sprintf("credentials request %s has been provisioned", name))
+	}
+}
+
+func (p *AWSBlobStorageProvider) recordEvent(bs *v1alpha1.BlobStorage, eventType, reason, msg string) {
+	if p.Recorder != nil {
+		p.Recorder.Event(bs, eventType, reason, msg)
+	}
+}
+
 // DeleteStorage Delete S3 bucket and credentials to add objects to it
 func (p *AWSBlobStorageProvider) DeleteStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) error {
 	// resolve bucket information for bucket created by provider
@@ -172,13 +229,12 @@ func (p *AWSBlobStorageProvider) DeleteStorage(ctx context.Context, client clien
 	if err != nil {
 		return errorUtil.Wrap(err, "failed to reconcile aws provider credentials")
 	}
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region:      aws.String(stratCfg.Region),
-		Credentials: credentials.NewStaticCredentials(providerCreds.AccessKeyID, providerCreds.SecretAccessKey, ""),
-	}))
 
 	// delete the bucket that was created by the provider
-	s3svc := s3.New(sess)
+	s3svc, err := p.S3Client(stratCfg, providerCreds)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to create s3 client")
+	}
 	_, err = s3svc.DeleteBucket(&s3.DeleteBucketInput{
 		Bucket: bucketCreateCfg.Bucket,
 	})
@@ -206,7 +262,8 @@ func (p *AWSBlobStorageProvider) DeleteStorage(ctx context.Context, client clien
 			Namespace: bs.Namespace,
 		},
 	}
-	if err := client.Delete(ctx, putObjCredReq); err != nil {
+	// the credentials request is already gone if a previous deletion failed after removing it
+	if err := client.Delete(ctx, putObjCredReq); err != nil && !errors.IsNotFound(err) {
 		return errorUtil.Wrapf(err, "failed to delete credential request %s", putObjCredsName)
 	}
 
@@ -218,6 +275,180 @@ func (p *AWSBlobStorageProvider) DeleteStorage(ctx context.Context, client clien
 	return nil
 }
 
+// PlanStorage Describe the changes CreateStorage or DeleteStorage would make, only read-only aws apis are called and
+// only once the provider credentials have been provisioned
+func (p *AWSBlobStorageProvider) PlanStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) ([]string, error) {
+	bucketCreateCfg, stratCfg, err := p.getS3BucketConfig(ctx, bs)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to retrieve aws s3 bucket config for instance %s", bs.Name)
+	}
+	if bucketCreateCfg.Bucket == nil {
+		bucketCreateCfg.Bucket = aws.String(fmt.Sprintf("%s-%s", bs.Namespace, bs.Name))
+	}
+	bucket := *bucketCreateCfg.Bucket
+	endUserCredsName := fmt.Sprintf("cloud-resources-aws-s3-%s-credentials", bs.Name)
+
+	if bs.GetDeletionTimestamp() != nil {
+		return []string{
+			fmt.Sprintf("delete s3 bucket %s in region %s", bucket, stratCfg.Region),
+			fmt.Sprintf("delete credentials request %s in namespace %s", endUserCredsName, bs.Namespace),
+		}, nil
+	}
+
+	var actions []string
+	for _, credsName := range []string{endUserCredsName, p.CredentialManager.ProviderCredentialName} {
+		exists, err := p.CredentialManager.CredentialsRequestExists(ctx, credsName, bs.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			actions = append(actions, fmt.Sprintf("create credentials request %s in namespace %s", credsName, bs.Namespace))
+		}
+	}
+
+	createAction := fmt.Sprintf("create s3 bucket %s in region %s with settings %s", bucket, stratCfg.Region, string(stratCfg.RawStrategy))
+	if len(stratCfg.CORSRules) > 0 || len(stratCfg.PolicyStatements) > 0 {
+		createAction += fmt.Sprintf(", %d cors rules and %d policy statements", len(stratCfg.CORSRules), len(stratCfg.PolicyStatements))
+	}
+	providerCreds, err := p.CredentialManager.GetProvisionedCredentials(ctx, p.CredentialManager.ProviderCredentialName, bs.Namespace)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get aws blob storage provider credentials")
+	}
+	if providerCreds == nil {
+		// existing buckets can't be listed without credentials
+		return append(actions, createAction+" if it doesn't exist"), nil
+	}
+
+	s3svc, err := p.S3Client(stratCfg, providerCreds)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create s3 client")
+	}
+	listOutput, err := s3svc.ListBuckets(nil)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list s3 buckets")
+	}
+	if !hasBucket(listOutput.Buckets, bucket) {
+		return append(actions, createAction), nil
+	}
+	drift, err := compareStorage(s3svc, bucketCreateCfg, stratCfg, bs.Status.ManagedSettings)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range drift {
+		if isFixable(d) {
+			actions = append(actions, fmt.Sprintf("set %s of s3 bucket %s to %s", d.Field, bucket, d.Desired))
+			continue
+		}
+		actions = append(actions, fmt.Sprintf("report drift of s3 bucket %s, %s", bucket, d.String()))
+	}
+	return actions, nil
+}
+
+// DescribeStorage Describe the settings of the bucket of an instance and compare them with its strategy, only
+// read-only aws apis are called. No drift is returned if the bucket or the provider credentials don't exist yet
+func (p *AWSBlobStorageProvider) DescribeStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) ([]providers.Drift, error) {
+	bucketCreateCfg, stratCfg, err := p.getS3BucketConfig(ctx, bs)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to retrieve aws s3 bucket config for instance %s", bs.Name)
+	}
+	if bucketCreateCfg.Bucket == nil {
+		bucketCreateCfg.Bucket = aws.String(fmt.Sprintf("%s-%s", bs.Namespace, bs.Name))
+	}
+	providerCreds, err := p.CredentialManager.GetProvisionedCredentials(ctx, p.CredentialManager.ProviderCredentialName, bs.Namespace)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get aws blob storage provider credentials")
+	}
+	if providerCreds == nil {
+		return nil, nil
+	}
+	s3svc, err := p.S3Client(stratCfg, providerCreds)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create s3 client")
+	}
+	listOutput, err := s3svc.ListBuckets(nil)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list s3 buckets")
+	}
+	if !hasBucket(listOutput.Buckets, *bucketCreateCfg.Bucket) {
+		return nil, nil
+	}
+	return compareStorage(s3svc, bucketCreateCfg, stratCfg, bs.Status.ManagedSettings)
+}
+
+// CorrectDrift Correct the settings of the bucket of an instance that differ from its strategy and can be changed
+// safely, drift is as returned by DescribeStorage so the bucket isn't described again. The drift is returned with the
+// corrected settings marked as fixed, and the cors rules and policy now managed are recorded in the status of the
+// instance
+func (p *AWSBlobStorageProvider) CorrectDrift(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage, drift []providers.Drift) ([]providers.Drift, error) {
+	bucketCreateCfg, stratCfg, err := p.getS3BucketConfig(ctx, bs)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to retrieve aws s3 bucket config for instance %s", bs.Name)
+	}
+	var fixable bool
+	for _, d := range drift {
+		fixable = fixable || isFixable(d)
+	}
+	if !fixable {
+		bs.Status.ManagedSettings = managedBucketSettings(stratCfg)
+		return drift, nil
+	}
+	if bucketCreateCfg.Bucket == nil {
+		bucketCreateCfg.Bucket = aws.String(fmt.Sprintf("%s-%s", bs.Namespace, bs.Name))
+	}
+	bucket := *bucketCreateCfg.Bucket
+	providerCreds, err := p.CredentialManager.GetProvisionedCredentials(ctx, p.CredentialManager.ProviderCredentialName, bs.Namespace)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get aws blob storage provider credentials")
+	}
+	if providerCreds == nil {
+		return drift, nil
+	}
+	s3svc, err := p.S3Client(stratCfg, providerCreds)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create s3 client")
+	}
+	desiredPolicies, err := desiredBucketPolicies(bucket, stratCfg, bs.Status.ManagedSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	corrected := append([]providers.Drift{}, drift...)
+	for i, d := range corrected {
+		switch d.Field {
+		case driftFieldACL:
+			_, err = s3svc.PutBucketAcl(&s3.PutBucketAclInput{
+				Bucket: aws.String(bucket),
+				ACL:    bucketCreateCfg.ACL,
+			})
+			if err != nil {
+				err = errorUtil.Wrapf(err, "failed to correct acl of s3 bucket %s", bucket)
+			}
+		case driftFieldCORS:
+			err = putBucketCORS(s3svc, bucket, desiredPolicies.CORSRules)
+		case driftFieldPolicy:
+			err = putBucketPolicy(s3svc, bucket, desiredPolicies.Policy)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		corrected[i].Fixed = true
+	}
+	logCorrectedDrift(bs, corrected)
+	bs.Status.ManagedSettings = managedBucketSettings(stratCfg)
+	return corrected, nil
+}
+
+func hasBucket(buckets []*s3.Bucket, name string) bool {
+	for _, b := range buckets {
+		if aws.StringValue(b.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *AWSBlobStorageProvider) getS3BucketConfig(ctx context.Context, bs *v1alpha1.BlobStorage) (*s3.CreateBucketInput, *StrategyConfig, error) {
 	stratCfg, err := p.ConfigManager.ReadBlobStorageStrategy(ctx, bs.Spec.Tier)
 	if err != nil {
@@ -226,11 +457,43 @@ func (p *AWSBlobStorageProvider) getS3BucketConfig(ctx context.Context, bs *v1al
 	if stratCfg.Region == "" {
 		stratCfg.Region = defaultRegion
 	}
+	if bs.Spec.Overrides != nil {
+		if stratCfg, err = ApplyOverrides(stratCfg, bs.Spec.Overrides.Raw); err != nil {
+			return nil, nil, err
+		}
+	}
+	if stratCfg, err = ApplySpecPolicies(stratCfg, bs.Spec); err != nil {
+		return nil, nil, err
+	}
 
-	// delete the s3 bucket created by the provider
-	s3cbi := &s3.CreateBucketInput{}
-	if err = json.Unmarshal(stratCfg.RawStrategy, s3cbi); err != nil {
-		return nil, nil, errorUtil.Wrap(err, "failed to unmarshal aws s3 configuration")
+	s3cbi, err := buildCreateBucketInput(stratCfg)
+	if err != nil {
+		if bs.Spec.Overrides != nil {
+			return nil, nil, errorUtil.Wrapf(providers.ErrInvalidOverrides, "strategy is invalid after applying overrides, %s", err.Error())
+		}
+		return nil, nil, err
 	}
 	return s3cbi, stratCfg, nil
 }
+
+// ValidateBlobStorageStrategy Check a strategy can be used to create an s3 bucket
+func ValidateBlobStorageStrategy(stratCfg *StrategyConfig) error {
+	if err := validateEndpoint(stratCfg); err != nil {
+		return err
+	}
+	if err := validateBucketPolicies(stratCfg); err != nil {
+		return err
+	}
+	_, err := buildCreateBucketInput(stratCfg)
+	return err
+}
+
+// buildCreateBucketInput Build the create bucket input from a strategy, settings applied after a bucket is created
+// such as versioning aren't part of the input so they're rejected as unknown fields
+func buildCreateBucketInput(stratCfg *StrategyConfig) (*s3.CreateBucketInput, error) {
+	s3cbi := &s3.CreateBucketInput{}
+	if err := strictUnmarshal(stratCfg.RawStrategy, s3cbi); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to unmarshal aws s3 configuration, only fields of the s3 create bucket input are supported and settings applied after a bucket is created such as versioning are not")
+	}
+	return s3cbi, nil
+}
@@ -0,0 +1,557 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	s3fake "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/fake"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	v12 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAWSBlobStorageProvider_recordCredentialEvents(t *testing.T) {
+	cases := []struct {
+		name           string
+		before         *v1.CredentialsRequest
+		expectedEvents []string
+	}{
+		{
+			name:           "test created and ready events are recorded for new credentials request",
+			before:         nil,
+			expectedEvents: []string{resources.EventReasonCredentialsRequestCreated, resources.EventReasonCredentialsReady},
+		},
+		{
+			name:           "test ready event is recorded for existing credentials request that wasn't provisioned",
+			before:         &v1.CredentialsRequest{},
+			expectedEvents: []string{resources.EventReasonCredentialsReady},
+		},
+		{
+			name: "test no events are recorded for provisioned credentials request",
+			before: &v1.CredentialsRequest{
+				Status: v1.CredentialsRequestStatus{
+					Provisioned: true,
+				},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			p := &AWSBlobStorageProvider{
+				Recorder: recorder,
+			}
+			p.recordCredentialEvents(&v1alpha1.BlobStorage{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+			}, "test", tc.before)
+			close(recorder.Events)
+			var reasons []string
+			for e := range recorder.Events {
+				reasons = append(reasons, e)
+			}
+			if len(reasons) != len(tc.expectedEvents) {
+				t.Fatalf("unexpected events, expected %v but got %v", tc.expectedEvents, reasons)
+			}
+			for i, reason := range tc.expectedEvents {
+				if !strings.HasPrefix(reasons[i], "Normal "+reason+" ") {
+					t.Fatalf("unexpected event, expected reason %s but got %s", reason, reasons[i])
+				}
+			}
+		})
+	}
+}
+
+func buildProviderTestScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{v1.AddToScheme, v12.AddToScheme, v1alpha1.SchemeBuilder.AddToScheme} {
+		if err := add(scheme); err != nil {
+			return nil, err
+		}
+	}
+	return scheme, nil
+}
+
+// buildProvisionedCredentials Build a provisioned credential request and the secret holding its credentials
+func buildProvisionedCredentials(name, ns string) []runtime.Object {
+	return []runtime.Object{
+		&v1.CredentialsRequest{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.CredentialsRequestSpec{
+				SecretRef: v12.ObjectReference{
+					Name:      name,
+					Namespace: ns,
+				},
+			},
+			Status: v1.CredentialsRequestStatus{
+				Provisioned: true,
+			},
+		},
+		&v12.Secret{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Data: map[string][]byte{
+				defaultCredentialsKeyIDName:     []byte("testkey"),
+				defaultCredentialsSecretKeyName: []byte("testsecret"),
+			},
+		},
+	}
+}
+
+// buildProviderTestClient Build a client holding a blob storage instance of the test tier along with the strategy
+// config and provisioned credentials the provider needs
+func buildProviderTestClient(scheme *runtime.Scheme, bs *v1alpha1.BlobStorage) client.Client {
+	objs := []runtime.Object{bs, buildProviderTestConfigMap()}
+	objs = append(objs, buildProvisionedCredentials(defaultProviderCredentialName, bs.Namespace)...)
+	objs = append(objs, buildProvisionedCredentials(fmt.Sprintf("cloud-resources-aws-s3-%s-credentials", bs.Name), bs.Namespace)...)
+	return fake.NewFakeClientWithScheme(scheme, objs...)
+}
+
+// buildProviderTestConfigMap Build the strategy config defining the test tier
+func buildProviderTestConfigMap() *v12.ConfigMap {
+	return &v12.ConfigMap{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      DefaultConfigMapName,
+			Namespace: DefaultConfigMapNamespace,
+		},
+		Data: map[string]string{
+			"blobstorage": "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {\"ACL\": \"private\"}}}",
+		},
+	}
+}
+
+func buildTestBlobStorage() *v1alpha1.BlobStorage {
+	return &v1alpha1.BlobStorage{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.BlobStorageSpec{
+			Tier: "test",
+		},
+	}
+}
+
+func TestAWSBlobStorageProvider_CreateStorage(t *testing.T) {
+	scheme, err := buildProviderTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	cases := []struct {
+		name          string
+		existing      *s3fake.Bucket
+		errors        map[string]error
+		expectError   bool
+		expectedACL   string
+		expectedDrift []providers.Drift
+	}{
+		{
+			name:        "test bucket is created from strategy",
+			expectedACL: s3.BucketCannedACLPrivate,
+		},
+		{
+			name: "test existing bucket is adopted and its acl corrected",
+			existing: &s3fake.Bucket{
+				Region: "eu-west-1",
+				ACL:    s3.BucketCannedACLPublicRead,
+			},
+			expectedACL: s3.BucketCannedACLPrivate,
+		},
+		{
+			name: "test region drift of existing bucket is reported but not fixed",
+			existing: &s3fake.Bucket{
+				Region: "us-east-1",
+				ACL:    s3.BucketCannedACLPrivate,
+			},
+			expectedACL: s3.BucketCannedACLPrivate,
+			expectedDrift: []providers.Drift{
+				{Field: driftFieldRegion, Desired: "eu-west-1", Actual: "us-east-1"},
+			},
+		},
+		{
+			name: "test error is returned when bucket can't be created",
+			errors: map[string]error{
+				"CreateBucket": awserr.New(s3.ErrCodeBucketAlreadyExists, "bucket is owned by another account", nil),
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bs := buildTestBlobStorage()
+			s3svc := s3fake.NewS3("us-east-1")
+			s3svc.Errors = tc.errors
+			if tc.existing != nil {
+				s3svc.AddBucket("test-test", tc.existing)
+			}
+			p := NewAWSBlobStorageProvider(buildProviderTestClient(scheme, bs), nil)
+			p.S3Client = func(stratCfg *StrategyConfig, _ *AWSCredentials) (s3iface.S3API, error) {
+				s3svc.Region = stratCfg.Region
+				return s3svc, nil
+			}
+
+			bsi, err := p.CreateStorage(context.TODO(), p.Client, bs)
+			if err != nil {
+				if tc.expectError {
+					return
+				}
+				t.Fatal("failed to create storage", err)
+			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			b := s3svc.Bucket("test-test")
+			if b == nil {
+				t.Fatal("expected bucket test-test to exist")
+			}
+			if tc.existing == nil && b.Region != "eu-west-1" {
+				t.Fatalf("unexpected region, expected eu-west-1 but got %s", b.Region)
+			}
+			drift, err := p.DescribeStorage(context.TODO(), p.Client, bs)
+			if err != nil {
+				t.Fatal("failed to describe storage", err)
+			}
+			drift, err = p.CorrectDrift(context.TODO(), p.Client, bs, drift)
+			if err != nil {
+				t.Fatal("failed to correct drift", err)
+			}
+			var unfixed []providers.Drift
+			for _, d := range drift {
+				if !d.Fixed {
+					unfixed = append(unfixed, d)
+				}
+			}
+			if !reflect.DeepEqual(unfixed, tc.expectedDrift) {
+				t.Fatalf("unexpected drift, expected %v but got %v", tc.expectedDrift, unfixed)
+			}
+			if b.ACL != tc.expectedACL {
+				t.Fatalf("unexpected acl, expected %s but got %s", tc.expectedACL, b.ACL)
+			}
+			// the bucket is only described once per reconcile
+			if calls := s3svc.Calls["GetBucketAcl"]; calls != 1 {
+				t.Fatalf("unexpected calls, expected acl to be read once but got %d", calls)
+			}
+			if bucket := string(bsi.DeploymentDetails.Data()[dataBucketName]); bucket != "test-test" {
+				t.Fatalf("unexpected bucket name, expected test-test but got %s", bucket)
+			}
+		})
+	}
+}
+
+func TestAWSBlobStorageProvider_CorrectDrift(t *testing.T) {
+	scheme, err := buildProviderTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	bs := buildTestBlobStorage()
+	bs.Spec.CORSRules = []v1alpha1.CORSRule{{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}, MaxAgeSeconds: 300}}
+	bs.Spec.PolicyStatements = []runtime.RawExtension{{Raw: []byte(`{"Effect": "Allow", "Principal": {"AWS": "123456789012"}, "Action": "s3:GetObject"}`)}}
+	s3svc := s3fake.NewS3("eu-west-1")
+	s3svc.AddBucket("test-test", &s3fake.Bucket{Region: "eu-west-1", ACL: s3.BucketCannedACLPublicRead})
+	p := NewAWSBlobStorageProvider(buildProviderTestClient(scheme, bs), nil)
+	p.S3Client = func(stratCfg *StrategyConfig, _ *AWSCredentials) (s3iface.S3API, error) {
+		return s3svc, nil
+	}
+
+	drift, err := p.DescribeStorage(context.TODO(), p.Client, bs)
+	if err != nil {
+		t.Fatal("failed to describe storage", err)
+	}
+	corrected, err := p.CorrectDrift(context.TODO(), p.Client, bs, drift)
+	if err != nil {
+		t.Fatal("failed to correct drift", err)
+	}
+	var fields []string
+	for _, d := range corrected {
+		if !d.Fixed {
+			t.Fatalf("unexpected drift, expected %s to be fixed", d.Field)
+		}
+		fields = append(fields, d.Field)
+	}
+	if expected := []string{driftFieldACL, driftFieldCORS, driftFieldPolicy}; !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("unexpected drift, expected %v but got %v", expected, fields)
+	}
+	if drift[0].Fixed {
+		t.Fatal("unexpected drift, expected drift passed to CorrectDrift to be left unchanged")
+	}
+	b := s3svc.Bucket("test-test")
+	if b.ACL != s3.BucketCannedACLPrivate || len(b.CORSRules) != 1 || b.Policy == "" {
+		t.Fatalf("unexpected bucket, expected acl, cors rules and policy to be corrected but got %s, %v and %s", b.ACL, b.CORSRules, b.Policy)
+	}
+	if reads := s3svc.Calls["GetBucketCors"] + s3svc.Calls["GetBucketPolicy"]; reads != 2 {
+		t.Fatalf("unexpected calls, expected cors rules and policy to be read once but got %d reads", reads)
+	}
+
+	drift, err = p.DescribeStorage(context.TODO(), p.Client, bs)
+	if err != nil {
+		t.Fatal("failed to describe storage", err)
+	}
+	if len(drift) != 0 {
+		t.Fatalf("unexpected drift, expected none once corrected but got %v", drift)
+	}
+	calls := len(s3svc.Calls)
+	if _, err = p.CorrectDrift(context.TODO(), p.Client, bs, []providers.Drift{{Field: driftFieldRegion, Desired: "eu-west-1", Actual: "us-east-1"}}); err != nil {
+		t.Fatal("failed to correct drift", err)
+	}
+	if len(s3svc.Calls) != calls {
+		t.Fatalf("unexpected calls, expected no s3 calls without fixable drift but got %v", s3svc.Calls)
+	}
+}
+
+func TestAWSBlobStorageProvider_CorrectDriftRemovesUnsetPolicies(t *testing.T) {
+	scheme, err := buildProviderTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	bs := buildTestBlobStorage()
+	bs.Spec.CORSRules = []v1alpha1.CORSRule{{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}}}
+	bs.Spec.PolicyStatements = []runtime.RawExtension{{Raw: []byte(`{"Effect": "Allow", "Principal": {"AWS": "123456789012"}, "Action": "s3:GetObject"}`)}}
+	s3svc := s3fake.NewS3("eu-west-1")
+	s3svc.AddBucket("test-test", &s3fake.Bucket{Region: "eu-west-1"})
+	p := NewAWSBlobStorageProvider(buildProviderTestClient(scheme, bs), nil)
+	p.S3Client = func(stratCfg *StrategyConfig, _ *AWSCredentials) (s3iface.S3API, error) {
+		return s3svc, nil
+	}
+	reconcileDrift := func() []providers.Drift {
+		drift, err := p.DescribeStorage(context.TODO(), p.Client, bs)
+		if err != nil {
+			t.Fatal("failed to describe storage", err)
+		}
+		if drift, err = p.CorrectDrift(context.TODO(), p.Client, bs, drift); err != nil {
+			t.Fatal("failed to correct drift", err)
+		}
+		return drift
+	}
+
+	// cors rules and policy set in the spec are added and recorded as managed
+	if drift := reconcileDrift(); len(drift) != 2 {
+		t.Fatalf("unexpected drift, expected cors rules and policy to be corrected but got %v", drift)
+	}
+	b := s3svc.Bucket("test-test")
+	if len(b.CORSRules) != 1 || b.Policy == "" {
+		t.Fatalf("unexpected bucket, expected cors rules and policy to be set but got %v and %s", b.CORSRules, b.Policy)
+	}
+	if expected := []string{driftFieldCORS, driftFieldPolicy}; !reflect.DeepEqual(bs.Status.ManagedSettings, expected) {
+		t.Fatalf("unexpected managed settings, expected %v but got %v", expected, bs.Status.ManagedSettings)
+	}
+
+	// removing them from the spec removes them from the bucket
+	bs.Spec.CORSRules = nil
+	bs.Spec.PolicyStatements = nil
+	if drift := reconcileDrift(); len(drift) != 2 {
+		t.Fatalf("unexpected drift, expected cors rules and policy to be removed but got %v", drift)
+	}
+	if len(b.CORSRules) != 0 || b.Policy != "" {
+		t.Fatalf("unexpected bucket, expected cors rules and policy to be removed but got %v and %s", b.CORSRules, b.Policy)
+	}
+	if s3svc.Calls["DeleteBucketCors"] != 1 || s3svc.Calls["DeleteBucketPolicy"] != 1 {
+		t.Fatalf("unexpected calls, expected cors rules and policy to be deleted once but got %v", s3svc.Calls)
+	}
+	if len(bs.Status.ManagedSettings) != 0 {
+		t.Fatalf("unexpected managed settings, expected none but got %v", bs.Status.ManagedSettings)
+	}
+
+	// settings that are no longer managed aren't read again
+	reads := s3svc.Calls["GetBucketCors"] + s3svc.Calls["GetBucketPolicy"]
+	if drift := reconcileDrift(); len(drift) != 0 {
+		t.Fatalf("unexpected drift, expected none but got %v", drift)
+	}
+	if after := s3svc.Calls["GetBucketCors"] + s3svc.Calls["GetBucketPolicy"]; after != reads {
+		t.Fatalf("unexpected calls, expected unmanaged cors rules and policy not to be read but got %d reads", after-reads)
+	}
+}
+
+func TestAWSBlobStorageProvider_PlanStorage(t *testing.T) {
+	scheme, err := buildProviderTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	// every mutating s3 operation fails, planning must only call read-only apis
+	mutatingOperations := []string{"CreateBucket", "DeleteBucket", "PutBucketAcl", "PutBucketCors", "DeleteBucketCors", "PutBucketPolicy", "DeleteBucketPolicy"}
+	cases := []struct {
+		name            string
+		provisioned     bool
+		deleting        bool
+		existing        *s3fake.Bucket
+		expectedActions []string
+	}{
+		{
+			name: "test credentials requests and bucket are planned without provider credentials",
+			expectedActions: []string{
+				"create credentials request cloud-resources-aws-s3-test-credentials in namespace test",
+				"create credentials request " + defaultProviderCredentialName + " in namespace test",
+				"create s3 bucket test-test in region eu-west-1 with settings {\"ACL\":\"private\"} if it doesn't exist",
+			},
+		},
+		{
+			name:        "test bucket is planned when it doesn't exist",
+			provisioned: true,
+			expectedActions: []string{
+				"create s3 bucket test-test in region eu-west-1 with settings {\"ACL\":\"private\"}",
+			},
+		},
+		{
+			name:        "test drift of existing bucket is planned",
+			provisioned: true,
+			existing:    &s3fake.Bucket{Region: "us-east-1", ACL: s3.BucketCannedACLPublicRead},
+			expectedActions: []string{
+				"report drift of s3 bucket test-test, region is us-east-1 but should be eu-west-1",
+				"set acl of s3 bucket test-test to private",
+			},
+		},
+		{
+			name:        "test deletion is planned for instance being deleted",
+			provisioned: true,
+			deleting:    true,
+			existing:    &s3fake.Bucket{Region: "eu-west-1"},
+			expectedActions: []string{
+				"delete s3 bucket test-test in region eu-west-1",
+				"delete credentials request cloud-resources-aws-s3-test-credentials in namespace test",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bs := buildTestBlobStorage()
+			if tc.deleting {
+				now := metav1.Now()
+				bs.DeletionTimestamp = &now
+			}
+			s3svc := s3fake.NewS3("eu-west-1")
+			s3svc.Errors = map[string]error{}
+			for _, op := range mutatingOperations {
+				s3svc.Errors[op] = awserr.New("AccessDenied", "mutating operation called while planning", nil)
+			}
+			if tc.existing != nil {
+				s3svc.AddBucket("test-test", tc.existing)
+			}
+			c := fake.NewFakeClientWithScheme(scheme, bs, buildProviderTestConfigMap())
+			if tc.provisioned {
+				c = buildProviderTestClient(scheme, bs)
+			}
+			credsBefore, secretsBefore := countCredentialObjects(t, c)
+			p := NewAWSBlobStorageProvider(c, nil)
+			p.S3Client = func(stratCfg *StrategyConfig, _ *AWSCredentials) (s3iface.S3API, error) {
+				return s3svc, nil
+			}
+
+			actions, err := p.PlanStorage(context.TODO(), c, bs)
+			if err != nil {
+				t.Fatal("failed to plan storage", err)
+			}
+			if !reflect.DeepEqual(actions, tc.expectedActions) {
+				t.Fatalf("unexpected actions, expected %v but got %v", tc.expectedActions, actions)
+			}
+			for _, op := range mutatingOperations {
+				if s3svc.Calls[op] != 0 {
+					t.Fatalf("unexpected call to mutating operation %s while planning", op)
+				}
+			}
+			if tc.existing != nil && s3svc.Bucket("test-test") == nil {
+				t.Fatal("expected bucket test-test to be kept while planning")
+			}
+			credsAfter, secretsAfter := countCredentialObjects(t, c)
+			if credsAfter != credsBefore || secretsAfter != secretsBefore {
+				t.Fatalf("unexpected objects, expected %d credentials requests and %d secrets but got %d and %d", credsBefore, secretsBefore, credsAfter, secretsAfter)
+			}
+		})
+	}
+}
+
+// countCredentialObjects Count the credentials requests and secrets held by a client
+func countCredentialObjects(t *testing.T, c client.Client) (int, int) {
+	crs := &v1.CredentialsRequestList{}
+	if err := c.List(context.TODO(), &client.ListOptions{}, crs); err != nil {
+		t.Fatal("failed to list credentials requests", err)
+	}
+	secrets := &v12.SecretList{}
+	if err := c.List(context.TODO(), &client.ListOptions{}, secrets); err != nil {
+		t.Fatal("failed to list secrets", err)
+	}
+	return len(crs.Items), len(secrets.Items)
+}
+
+func TestAWSBlobStorageProvider_DeleteStorage(t *testing.T) {
+	scheme, err := buildProviderTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	cases := []struct {
+		name        string
+		existing    *s3fake.Bucket
+		expectError bool
+	}{
+		{
+			name:     "test empty bucket is deleted",
+			existing: &s3fake.Bucket{Region: "eu-west-1"},
+		},
+		{
+			name:     "test missing bucket is treated as deleted",
+			existing: nil,
+		},
+		{
+			name: "test error is returned when bucket isn't empty",
+			existing: &s3fake.Bucket{
+				Region:  "eu-west-1",
+				Objects: map[string][]byte{"test": []byte("test")},
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bs := buildTestBlobStorage()
+			bs.Finalizers = []string{defaultFinalizer}
+			s3svc := s3fake.NewS3("eu-west-1")
+			if tc.existing != nil {
+				s3svc.AddBucket("test-test", tc.existing)
+			}
+			p := NewAWSBlobStorageProvider(buildProviderTestClient(scheme, bs), nil)
+			p.S3Client = func(stratCfg *StrategyConfig, _ *AWSCredentials) (s3iface.S3API, error) {
+				s3svc.Region = stratCfg.Region
+				return s3svc, nil
+			}
+
+			err := p.DeleteStorage(context.TODO(), p.Client, bs)
+			if err != nil {
+				if tc.expectError {
+					if s3svc.Bucket("test-test") == nil {
+						t.Fatal("expected bucket to be kept after failed deletion")
+					}
+					return
+				}
+				t.Fatal("failed to delete storage", err)
+			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if s3svc.Bucket("test-test") != nil {
+				t.Fatal("expected bucket test-test to be deleted")
+			}
+			if resources.HasFinalizer(&bs.ObjectMeta, defaultFinalizer) {
+				t.Fatal("expected finalizer to be removed")
+			}
+		})
+	}
+}
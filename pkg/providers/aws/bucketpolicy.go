@@ -0,0 +1,424 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/errcodes"
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	driftFieldCORS   = "cors"
+	driftFieldPolicy = "policy"
+
+	policyVersion = "2012-10-17"
+)
+
+// accountIDPattern Matches principals given as a bare account id, s3 stores them as the arn of the account root
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// corsMethods The methods s3 allows in cors rules
+var corsMethods = map[string]bool{"GET": true, "PUT": true, "POST": true, "DELETE": true, "HEAD": true}
+
+// bucketPolicies The cors rules and policy of a bucket, the policy is empty if the bucket has none
+type bucketPolicies struct {
+	CORSRules []v1alpha1.CORSRule
+	Policy    string
+	// ManageCORS and ManagePolicy are set when the strategy sets cors rules or policy statements, settings the
+	// strategy doesn't set are left as they are on the bucket
+	ManageCORS   bool
+	ManagePolicy bool
+}
+
+// ApplySpecPolicies Add the cors rules and policy statements set in the spec of a resource to those of its tier,
+// errors caused by invalid rules or statements in the spec have providers.ErrInvalidOverrides as their cause
+func ApplySpecPolicies(stratCfg *StrategyConfig, spec v1alpha1.BlobStorageSpec) (*StrategyConfig, error) {
+	if len(spec.CORSRules) == 0 && len(spec.PolicyStatements) == 0 {
+		return stratCfg, nil
+	}
+	applied := *stratCfg
+	if len(spec.CORSRules) > 0 {
+		applied.CORSRules = append(append([]v1alpha1.CORSRule{}, stratCfg.CORSRules...), spec.CORSRules...)
+	}
+	if len(spec.PolicyStatements) > 0 {
+		applied.PolicyStatements = append([]json.RawMessage{}, stratCfg.PolicyStatements...)
+		for _, st := range spec.PolicyStatements {
+			applied.PolicyStatements = append(applied.PolicyStatements, json.RawMessage(st.Raw))
+		}
+	}
+	if err := validateBucketPolicies(&applied); err != nil {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidOverrides, "invalid cors rules or policy statements, %s", err.Error())
+	}
+	return &applied, nil
+}
+
+// validateBucketPolicies Check the cors rules and policy statements of a strategy can be set on a bucket, statements
+// granting access to any principal are only valid if the strategy allows public policies
+func validateBucketPolicies(stratCfg *StrategyConfig) error {
+	for i, r := range stratCfg.CORSRules {
+		if len(r.AllowedOrigins) == 0 || len(r.AllowedMethods) == 0 {
+			return errorUtil.New(fmt.Sprintf("cors rule %d must allow at least one origin and method", i))
+		}
+		for _, m := range r.AllowedMethods {
+			if !corsMethods[m] {
+				return errorUtil.New(fmt.Sprintf("cors rule %d allows unsupported method %s", i, m))
+			}
+		}
+	}
+	allowPublic := aws.BoolValue(stratCfg.AllowPublicPolicy)
+	for i, raw := range stratCfg.PolicyStatements {
+		var st map[string]interface{}
+		if err := json.Unmarshal(raw, &st); err != nil {
+			return errorUtil.Wrapf(err, "policy statement %d must be a json object", i)
+		}
+		if !allowPublic && isPublicStatement(st) {
+			return errorUtil.New(fmt.Sprintf("policy statement %d grants access to any principal, the tier doesn't allow public policies", i))
+		}
+	}
+	return nil
+}
+
+// isPublicStatement Check whether a policy statement allows access to any principal, either through a wildcard
+// principal or by allowing every principal except some
+func isPublicStatement(st map[string]interface{}) bool {
+	if st["Effect"] != "Allow" {
+		return false
+	}
+	if _, ok := st["NotPrincipal"]; ok {
+		return true
+	}
+	return isWildcard(st["Principal"])
+}
+
+func isWildcard(v interface{}) bool {
+	switch p := v.(type) {
+	case string:
+		return p == "*"
+	case []interface{}:
+		for _, e := range p {
+			if isWildcard(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, e := range p {
+			if isWildcard(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// desiredBucketPolicies Build the cors rules and policy a bucket should have from its strategy, statements without a
+// resource apply to the bucket and its objects. Cors rules and policies are only managed if the strategy sets them or
+// they're in the settings managed before, an empty list or a setting that's no longer set removes them from the bucket
+func desiredBucketPolicies(bucket string, stratCfg *StrategyConfig, managed []string) (*bucketPolicies, error) {
+	desired := &bucketPolicies{
+		CORSRules:    normalizeCORSRules(stratCfg.CORSRules),
+		ManageCORS:   stratCfg.CORSRules != nil || hasSetting(managed, driftFieldCORS),
+		ManagePolicy: stratCfg.PolicyStatements != nil || hasSetting(managed, driftFieldPolicy),
+	}
+	if len(stratCfg.PolicyStatements) == 0 {
+		return desired, nil
+	}
+	var statements []map[string]interface{}
+	for i, raw := range stratCfg.PolicyStatements {
+		var st map[string]interface{}
+		if err := json.Unmarshal(raw, &st); err != nil {
+			return nil, errorUtil.Wrapf(err, "policy statement %d must be a json object", i)
+		}
+		_, hasResource := st["Resource"]
+		_, hasNotResource := st["NotResource"]
+		if !hasResource && !hasNotResource {
+			st["Resource"] = []string{fmt.Sprintf("arn:aws:s3:::%s", bucket), fmt.Sprintf("arn:aws:s3:::%s/*", bucket)}
+		}
+		statements = append(statements, st)
+	}
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version":   policyVersion,
+		"Statement": statements,
+	})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to marshal bucket policy")
+	}
+	desired.Policy = string(policy)
+	return desired, nil
+}
+
+// managedBucketSettings Get the settings of a bucket managed by its strategy, recorded in the status of the instance
+// so they're removed from the bucket once the strategy stops setting them
+func managedBucketSettings(stratCfg *StrategyConfig) []string {
+	var managed []string
+	if stratCfg.CORSRules != nil {
+		managed = append(managed, driftFieldCORS)
+	}
+	if stratCfg.PolicyStatements != nil {
+		managed = append(managed, driftFieldPolicy)
+	}
+	return managed
+}
+
+func hasSetting(settings []string, setting string) bool {
+	for _, s := range settings {
+		if s == setting {
+			return true
+		}
+	}
+	return false
+}
+
+// describeBucketPolicies Read the cors rules and policy of an existing bucket that are managed by the desired policies
+func describeBucketPolicies(s3svc s3iface.S3API, bucket string, desired *bucketPolicies) (*bucketPolicies, error) {
+	actual := &bucketPolicies{}
+	if desired.ManageCORS {
+		corsOutput, err := s3svc.GetBucketCors(&s3.GetBucketCorsInput{
+			Bucket: aws.String(bucket),
+		})
+		if err != nil {
+			if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != errcodes.NoSuchCORSConfiguration {
+				return nil, errorUtil.Wrapf(err, "failed to get cors rules of s3 bucket %s", bucket)
+			}
+		} else {
+			actual.CORSRules = fromS3CORSRules(corsOutput.CORSRules)
+		}
+	}
+
+	if desired.ManagePolicy {
+		policyOutput, err := s3svc.GetBucketPolicy(&s3.GetBucketPolicyInput{
+			Bucket: aws.String(bucket),
+		})
+		if err != nil {
+			if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != errcodes.NoSuchBucketPolicy {
+				return nil, errorUtil.Wrapf(err, "failed to get policy of s3 bucket %s", bucket)
+			}
+		} else {
+			actual.Policy = aws.StringValue(policyOutput.Policy)
+		}
+	}
+	return actual, nil
+}
+
+// compareBucketPolicies Find the managed cors rules and policy of a bucket that differ from those of its strategy
+func compareBucketPolicies(desired, actual *bucketPolicies) []providers.Drift {
+	var drift []providers.Drift
+	if desired.ManageCORS && !reflect.DeepEqual(desired.CORSRules, actual.CORSRules) {
+		drift = append(drift, providers.Drift{Field: driftFieldCORS, Desired: formatCORSRules(desired.CORSRules), Actual: formatCORSRules(actual.CORSRules)})
+	}
+	if desired.ManagePolicy && !policyEqual(desired.Policy, actual.Policy) {
+		drift = append(drift, providers.Drift{Field: driftFieldPolicy, Desired: formatPolicy(desired.Policy), Actual: formatPolicy(actual.Policy)})
+	}
+	return drift
+}
+
+// applyBucketPolicies Set the cors rules and policy of a new bucket to those of its strategy, a new bucket has neither
+// so only those the strategy sets are applied
+func applyBucketPolicies(s3svc s3iface.S3API, bucket string, stratCfg *StrategyConfig) error {
+	desired, err := desiredBucketPolicies(bucket, stratCfg, nil)
+	if err != nil {
+		return err
+	}
+	if len(desired.CORSRules) > 0 {
+		if err = putBucketCORS(s3svc, bucket, desired.CORSRules); err != nil {
+			return err
+		}
+	}
+	if desired.Policy != "" {
+		if err = putBucketPolicy(s3svc, bucket, desired.Policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putBucketCORS(s3svc s3iface.S3API, bucket string, rules []v1alpha1.CORSRule) error {
+	if len(rules) == 0 {
+		if _, err := s3svc.DeleteBucketCors(&s3.DeleteBucketCorsInput{Bucket: aws.String(bucket)}); err != nil {
+			return errorUtil.Wrapf(err, "failed to remove cors rules of s3 bucket %s", bucket)
+		}
+		return nil
+	}
+	var s3Rules []*s3.CORSRule
+	for _, r := range rules {
+		s3Rules = append(s3Rules, &s3.CORSRule{
+			AllowedOrigins: aws.StringSlice(r.AllowedOrigins),
+			AllowedMethods: aws.StringSlice(r.AllowedMethods),
+			AllowedHeaders: aws.StringSlice(r.AllowedHeaders),
+			ExposeHeaders:  aws.StringSlice(r.ExposeHeaders),
+			MaxAgeSeconds:  aws.Int64(r.MaxAgeSeconds),
+		})
+	}
+	_, err := s3svc.PutBucketCors(&s3.PutBucketCorsInput{
+		Bucket:            aws.String(bucket),
+		CORSConfiguration: &s3.CORSConfiguration{CORSRules: s3Rules},
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to set cors rules of s3 bucket %s", bucket)
+	}
+	return nil
+}
+
+func putBucketPolicy(s3svc s3iface.S3API, bucket string, policy string) error {
+	if policy == "" {
+		if _, err := s3svc.DeleteBucketPolicy(&s3.DeleteBucketPolicyInput{Bucket: aws.String(bucket)}); err != nil {
+			return errorUtil.Wrapf(err, "failed to remove policy of s3 bucket %s", bucket)
+		}
+		return nil
+	}
+	_, err := s3svc.PutBucketPolicy(&s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucket),
+		Policy: aws.String(policy),
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to set policy of s3 bucket %s", bucket)
+	}
+	return nil
+}
+
+// fromS3CORSRules Convert the cors rules of a bucket so they can be compared with those of its strategy
+func fromS3CORSRules(s3Rules []*s3.CORSRule) []v1alpha1.CORSRule {
+	var rules []v1alpha1.CORSRule
+	for _, r := range s3Rules {
+		rules = append(rules, v1alpha1.CORSRule{
+			AllowedOrigins: aws.StringValueSlice(r.AllowedOrigins),
+			AllowedMethods: aws.StringValueSlice(r.AllowedMethods),
+			AllowedHeaders: aws.StringValueSlice(r.AllowedHeaders),
+			ExposeHeaders:  aws.StringValueSlice(r.ExposeHeaders),
+			MaxAgeSeconds:  aws.Int64Value(r.MaxAgeSeconds),
+		})
+	}
+	return normalizeCORSRules(rules)
+}
+
+// normalizeCORSRules Replace empty lists in cors rules with nil, s3 doesn't distinguish them
+func normalizeCORSRules(rules []v1alpha1.CORSRule) []v1alpha1.CORSRule {
+	var normalized []v1alpha1.CORSRule
+	for _, r := range rules {
+		normalized = append(normalized, v1alpha1.CORSRule{
+			AllowedOrigins: nilIfEmpty(r.AllowedOrigins),
+			AllowedMethods: nilIfEmpty(r.AllowedMethods),
+			AllowedHeaders: nilIfEmpty(r.AllowedHeaders),
+			ExposeHeaders:  nilIfEmpty(r.ExposeHeaders),
+			MaxAgeSeconds:  r.MaxAgeSeconds,
+		})
+	}
+	return normalized
+}
+
+func nilIfEmpty(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// policyEqual Check whether two policy documents are the same, ignoring formatting and the changes s3 makes to
+// policies it stores
+func policyEqual(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	var aDoc, bDoc interface{}
+	if json.Unmarshal([]byte(a), &aDoc) != nil || json.Unmarshal([]byte(b), &bDoc) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(normalizePolicy(aDoc), normalizePolicy(bDoc))
+}
+
+// normalizePolicy Convert a policy document to a form where equivalent policies are equal, lists of a single value
+// are replaced with the value and other lists are sorted as their order has no meaning
+func normalizePolicy(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		normalized := map[string]interface{}{}
+		for k, e := range val {
+			if k == "Principal" || k == "NotPrincipal" {
+				e = normalizePrincipal(e)
+			}
+			normalized[k] = normalizePolicy(e)
+		}
+		return normalized
+	case []interface{}:
+		var normalized []interface{}
+		for _, e := range val {
+			normalized = append(normalized, normalizePolicy(e))
+		}
+		if len(normalized) == 1 {
+			return normalized[0]
+		}
+		sort.Slice(normalized, func(i, j int) bool {
+			return fmt.Sprint(normalized[i]) < fmt.Sprint(normalized[j])
+		})
+		return normalized
+	}
+	return v
+}
+
+// normalizePrincipal Convert a principal to the form s3 stores it in, a wildcard principal is stored as a wildcard
+// aws principal and aws principals given as account ids as the arn of the account root
+func normalizePrincipal(p interface{}) interface{} {
+	switch val := p.(type) {
+	case string:
+		if val == "*" {
+			return map[string]interface{}{"AWS": "*"}
+		}
+	case map[string]interface{}:
+		awsPrincipal, ok := val["AWS"]
+		if !ok {
+			return val
+		}
+		normalized := map[string]interface{}{}
+		for k, e := range val {
+			normalized[k] = e
+		}
+		switch ids := awsPrincipal.(type) {
+		case string:
+			normalized["AWS"] = accountRootArn(ids)
+		case []interface{}:
+			var arns []interface{}
+			for _, id := range ids {
+				if s, ok := id.(string); ok {
+					arns = append(arns, accountRootArn(s))
+					continue
+				}
+				arns = append(arns, id)
+			}
+			normalized["AWS"] = arns
+		}
+		return normalized
+	}
+	return p
+}
+
+func accountRootArn(principal string) string {
+	if accountIDPattern.MatchString(principal) {
+		return fmt.Sprintf("arn:aws:iam::%s:root", principal)
+	}
+	return principal
+}
+
+func formatCORSRules(rules []v1alpha1.CORSRule) string {
+	var formatted []string
+	for _, r := range rules {
+		formatted = append(formatted, fmt.Sprintf("%s from %s", strings.Join(r.AllowedMethods, "/"), strings.Join(r.AllowedOrigins, ", ")))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(formatted, "; "))
+}
+
+func formatPolicy(policy string) string {
+	if policy == "" {
+		return "none"
+	}
+	return policy
+}
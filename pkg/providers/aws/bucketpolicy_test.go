@@ -0,0 +1,274 @@
+package aws
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	s3fake "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/fake"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidateBucketPolicies(t *testing.T) {
+	cases := []struct {
+		name        string
+		stratCfg    *StrategyConfig
+		expectError bool
+	}{
+		{
+			name: "test valid cors rules and private statement are accepted",
+			stratCfg: &StrategyConfig{
+				CORSRules:        []v1alpha1.CORSRule{{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET", "PUT"}}},
+				PolicyStatements: []json.RawMessage{json.RawMessage(`{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}, "Action": "s3:GetObject"}`)},
+			},
+		},
+		{
+			name: "test cors rule without origins is rejected",
+			stratCfg: &StrategyConfig{
+				CORSRules: []v1alpha1.CORSRule{{AllowedMethods: []string{"GET"}}},
+			},
+			expectError: true,
+		},
+		{
+			name: "test cors rule with unsupported method is rejected",
+			stratCfg: &StrategyConfig{
+				CORSRules: []v1alpha1.CORSRule{{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"PATCH"}}},
+			},
+			expectError: true,
+		},
+		{
+			name: "test statement that isn't an object is rejected",
+			stratCfg: &StrategyConfig{
+				PolicyStatements: []json.RawMessage{json.RawMessage(`"s3:GetObject"`)},
+			},
+			expectError: true,
+		},
+		{
+			name: "test public statement is rejected when tier doesn't allow it",
+			stratCfg: &StrategyConfig{
+				PolicyStatements: []json.RawMessage{json.RawMessage(`{"Effect": "Allow", "Principal": {"AWS": ["*"]}, "Action": "s3:GetObject"}`)},
+			},
+			expectError: true,
+		},
+		{
+			name: "test not principal statement is rejected when tier doesn't allow public policies",
+			stratCfg: &StrategyConfig{
+				PolicyStatements: []json.RawMessage{json.RawMessage(`{"Effect": "Allow", "NotPrincipal": {"AWS": "arn:aws:iam::123456789012:root"}, "Action": "s3:GetObject"}`)},
+			},
+			expectError: true,
+		},
+		{
+			name: "test public deny statement is accepted",
+			stratCfg: &StrategyConfig{
+				PolicyStatements: []json.RawMessage{json.RawMessage(`{"Effect": "Deny", "Principal": "*", "Action": "s3:DeleteObject"}`)},
+			},
+		},
+		{
+			name: "test public statement is accepted when tier allows it",
+			stratCfg: &StrategyConfig{
+				PolicyStatements:  []json.RawMessage{json.RawMessage(`{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject"}`)},
+				AllowPublicPolicy: aws.Bool(true),
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBucketPolicies(tc.stratCfg)
+			if tc.expectError && err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		})
+	}
+}
+
+func TestApplySpecPolicies(t *testing.T) {
+	tierRule := v1alpha1.CORSRule{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}}
+	specRule := v1alpha1.CORSRule{AllowedOrigins: []string{"https://app.example.com"}, AllowedMethods: []string{"PUT"}}
+	stratCfg := &StrategyConfig{
+		Region:    "eu-west-1",
+		CORSRules: []v1alpha1.CORSRule{tierRule},
+	}
+
+	applied, err := ApplySpecPolicies(stratCfg, v1alpha1.BlobStorageSpec{
+		CORSRules:        []v1alpha1.CORSRule{specRule},
+		PolicyStatements: []runtime.RawExtension{{Raw: []byte(`{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}, "Action": "s3:GetObject"}`)}},
+	})
+	if err != nil {
+		t.Fatal("failed to apply spec policies", err)
+	}
+	if len(applied.CORSRules) != 2 || applied.CORSRules[1].AllowedOrigins[0] != "https://app.example.com" {
+		t.Fatalf("unexpected cors rules, expected tier and spec rules but got %v", applied.CORSRules)
+	}
+	if len(applied.PolicyStatements) != 1 {
+		t.Fatalf("unexpected policy statements, expected 1 but got %d", len(applied.PolicyStatements))
+	}
+	if len(stratCfg.CORSRules) != 1 {
+		t.Fatalf("unexpected tier cors rules, expected tier strategy to be unchanged but got %v", stratCfg.CORSRules)
+	}
+
+	applied, err = ApplySpecPolicies(&StrategyConfig{}, v1alpha1.BlobStorageSpec{
+		CORSRules: []v1alpha1.CORSRule{specRule},
+	})
+	if err != nil {
+		t.Fatal("failed to apply spec policies", err)
+	}
+	if applied.PolicyStatements != nil {
+		t.Fatalf("unexpected policy statements, expected policy to be left unmanaged but got %v", applied.PolicyStatements)
+	}
+
+	_, err = ApplySpecPolicies(stratCfg, v1alpha1.BlobStorageSpec{
+		PolicyStatements: []runtime.RawExtension{{Raw: []byte(`{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject"}`)}},
+	})
+	if errorUtil.Cause(err) != providers.ErrInvalidOverrides {
+		t.Fatalf("unexpected error, expected %v but got %v", providers.ErrInvalidOverrides, err)
+	}
+}
+
+func TestApplyBucketPolicies(t *testing.T) {
+	stratCfg := &StrategyConfig{
+		CORSRules:        []v1alpha1.CORSRule{{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}, MaxAgeSeconds: 300}},
+		PolicyStatements: []json.RawMessage{json.RawMessage(`{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}, "Action": "s3:GetObject"}`)},
+	}
+	s3svc := s3fake.NewS3("eu-west-1")
+	s3svc.AddBucket("test", &s3fake.Bucket{Region: "eu-west-1"})
+
+	if err := applyBucketPolicies(s3svc, "test", stratCfg); err != nil {
+		t.Fatal("failed to apply bucket policies", err)
+	}
+	b := s3svc.Bucket("test")
+	if len(b.CORSRules) != 1 || aws.Int64Value(b.CORSRules[0].MaxAgeSeconds) != 300 {
+		t.Fatalf("unexpected cors rules, expected 1 rule with max age 300 but got %v", b.CORSRules)
+	}
+	var policy struct {
+		Statement []struct {
+			Resource []string
+		}
+	}
+	if err := json.Unmarshal([]byte(b.Policy), &policy); err != nil {
+		t.Fatal("failed to unmarshal bucket policy", err)
+	}
+	if len(policy.Statement) != 1 || len(policy.Statement[0].Resource) != 2 || policy.Statement[0].Resource[0] != "arn:aws:s3:::test" {
+		t.Fatalf("unexpected policy, expected statement for bucket and its objects but got %s", b.Policy)
+	}
+
+	s3svc.AddBucket("unmanaged", &s3fake.Bucket{Region: "eu-west-1"})
+	if err := applyBucketPolicies(s3svc, "unmanaged", &StrategyConfig{CORSRules: []v1alpha1.CORSRule{}}); err != nil {
+		t.Fatal("failed to apply bucket policies", err)
+	}
+	if calls := s3svc.Calls["PutBucketCors"] + s3svc.Calls["DeleteBucketCors"] + s3svc.Calls["PutBucketPolicy"] + s3svc.Calls["DeleteBucketPolicy"]; calls != 2 {
+		t.Fatalf("unexpected calls, expected only the cors rules and policy of the first bucket to be set but got %v", s3svc.Calls)
+	}
+}
+
+func TestCompareBucketPolicies(t *testing.T) {
+	s3svc := s3fake.NewS3("eu-west-1")
+	s3svc.AddBucket("test", &s3fake.Bucket{
+		Region:    "eu-west-1",
+		CORSRules: []*s3.CORSRule{{AllowedOrigins: aws.StringSlice([]string{"*"}), AllowedMethods: aws.StringSlice([]string{"GET"})}},
+		Policy:    `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject"}]}`,
+	})
+	cases := []struct {
+		name          string
+		stratCfg      *StrategyConfig
+		managed       []string
+		expectedDrift []string
+	}{
+		{
+			name:     "test cors rules and policy the strategy doesn't set are left alone",
+			stratCfg: &StrategyConfig{},
+		},
+		{
+			name:          "test cors rules and policy managed before are removed when the strategy no longer sets them",
+			stratCfg:      &StrategyConfig{},
+			managed:       []string{driftFieldCORS, driftFieldPolicy},
+			expectedDrift: []string{driftFieldCORS, driftFieldPolicy},
+		},
+		{
+			name:          "test empty cors rules and policy statements remove them",
+			stratCfg:      &StrategyConfig{CORSRules: []v1alpha1.CORSRule{}, PolicyStatements: []json.RawMessage{}},
+			expectedDrift: []string{driftFieldCORS, driftFieldPolicy},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s3svc.Calls = map[string]int{}
+			desired, err := desiredBucketPolicies("test", tc.stratCfg, tc.managed)
+			if err != nil {
+				t.Fatal("failed to build desired bucket policies", err)
+			}
+			actual, err := describeBucketPolicies(s3svc, "test", desired)
+			if err != nil {
+				t.Fatal("failed to describe bucket policies", err)
+			}
+			var fields []string
+			for _, d := range compareBucketPolicies(desired, actual) {
+				fields = append(fields, d.Field)
+			}
+			if !reflect.DeepEqual(fields, tc.expectedDrift) {
+				t.Fatalf("unexpected drift, expected %v but got %v", tc.expectedDrift, fields)
+			}
+			if len(tc.expectedDrift) == 0 && len(s3svc.Calls) != 0 {
+				t.Fatalf("unexpected calls, expected unmanaged settings not to be read but got %v", s3svc.Calls)
+			}
+		})
+	}
+}
+
+func TestPolicyEqual(t *testing.T) {
+	cases := []struct {
+		name     string
+		desired  string
+		actual   string
+		expected bool
+	}{
+		{
+			name:     "test formatting is ignored",
+			desired:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject"}]}`,
+			actual:   `{"Statement":[{"Action":"s3:GetObject","Effect":"Allow"}],"Version":"2012-10-17"}`,
+			expected: true,
+		},
+		{
+			name:     "test account id principal matches account root arn stored by s3",
+			desired:  `{"Statement": [{"Effect": "Allow", "Principal": {"AWS": ["123456789012"]}, "Action": ["s3:GetObject"]}]}`,
+			actual:   `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}, "Action": "s3:GetObject"}}`,
+			expected: true,
+		},
+		{
+			name:     "test wildcard principal matches wildcard aws principal",
+			desired:  `{"Statement": [{"Effect": "Deny", "Principal": "*", "Action": "s3:*"}]}`,
+			actual:   `{"Statement": [{"Effect": "Deny", "Principal": {"AWS": "*"}, "Action": "s3:*"}]}`,
+			expected: true,
+		},
+		{
+			name:     "test order of actions is ignored",
+			desired:  `{"Statement": [{"Effect": "Allow", "Action": ["s3:GetObject", "s3:PutObject"]}]}`,
+			actual:   `{"Statement": [{"Effect": "Allow", "Action": ["s3:PutObject", "s3:GetObject"]}]}`,
+			expected: true,
+		},
+		{
+			name:    "test different principals aren't equal",
+			desired: `{"Statement": [{"Effect": "Allow", "Principal": {"AWS": "123456789012"}, "Action": "s3:GetObject"}]}`,
+			actual:  `{"Statement": [{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::210987654321:root"}, "Action": "s3:GetObject"}]}`,
+		},
+		{
+			name:    "test missing policy isn't equal",
+			desired: `{"Statement": [{"Effect": "Allow", "Action": "s3:GetObject"}]}`,
+			actual:  "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if equal := policyEqual(tc.desired, tc.actual); equal != tc.expected {
+				t.Fatalf("unexpected result, expected %t but got %t", tc.expected, equal)
+			}
+		})
+	}
+}
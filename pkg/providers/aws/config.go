@@ -1,15 +1,21 @@
 package aws
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sync"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 	errorUtil "github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
 const (
@@ -17,18 +23,78 @@ const (
 	DefaultConfigMapNamespace = "kube-system"
 )
 
+var log = logf.Log.WithName("provider_aws")
+
 type StrategyConfig struct {
 	Region      string          `json:"region"`
 	RawStrategy json.RawMessage `json:"strategy"`
+	// Extends is the name of a tier defined in the same config whose strategy this tier is merged onto
+	Extends string `json:"extends,omitempty"`
+	// Base tiers can only be extended, they can't be used by resources
+	Base bool `json:"base,omitempty"`
+	// RegionOverrides are merged onto the resolved strategy when it's used in the region they're keyed by
+	RegionOverrides map[string]json.RawMessage `json:"regionOverrides,omitempty"`
+	// Overridable are the fields of the strategy resources can override, nested fields are separated by dots
+	Overridable []string `json:"overridable,omitempty"`
+	// Endpoint is the url of an s3-compatible store to use instead of aws, e.g. ceph rgw or minio
+	Endpoint string `json:"endpoint,omitempty"`
+	// ForcePathStyle addresses buckets in the path of requests rather than the host, required by most s3-compatible
+	// stores
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// DisableSSL calls the endpoint over plain http
+	DisableSSL bool `json:"disableSSL,omitempty"`
+	// CABundle is a PEM encoded bundle of certificates trusted when calling the endpoint
+	CABundle string `json:"caBundle,omitempty"`
+	// CORSRules are set on buckets of the tier, the cors rules of buckets are left alone if it's not set and removed
+	// if it's empty
+	CORSRules []v1alpha1.CORSRule `json:"corsRules,omitempty"`
+	// PolicyStatements are set as the bucket policy of buckets of the tier, statements without a resource apply to the
+	// bucket and its objects. The policy of buckets is left alone if it's not set and removed if it's empty
+	PolicyStatements []json.RawMessage `json:"policyStatements,omitempty"`
+	// AllowPublicPolicy allows policy statements granting access to any principal
+	AllowPublicPolicy *bool `json:"allowPublicPolicy,omitempty"`
 }
 
 type ConfigManager struct {
+	providers.Subscribers
 	configMapName      string
 	configMapNamespace string
-	client             client.Client
+	client             client.Reader
+	// Recorder is used to report invalid strategy config on the object it was read from, no events are recorded if nil
+	Recorder record.EventRecorder
+
+	mu sync.Mutex
+	// the strategies last read for each resource type and the version of the objects they were read from
+	results  map[providers.ResourceType]*strategyReadResult
+	versions map[providers.ResourceType]string
+	// the last valid strategy read for each tier of each resource type, used when a tier becomes invalid
+	lastKnownGood map[providers.ResourceType]map[string]*StrategyConfig
+	// the invalid strategy events recorded on the last read, used to avoid recording the same event on every read
+	lastEvents map[string]bool
+}
+
+// strategyValidators Validation for the strategies of each resource type supported by the provider
+var strategyValidators = map[providers.ResourceType]func(*StrategyConfig) error{
+	providers.BlobStorageResourceType: ValidateBlobStorageStrategy,
 }
 
-func NewConfigManager(cm string, namespace string, client client.Client) *ConfigManager {
+// invalidStrategyEvent An invalid strategy to be reported on the object it was read from
+type invalidStrategyEvent struct {
+	obj runtime.Object
+	msg string
+}
+
+// strategyReadResult The outcome of reading the strategies for all tiers of a resource type
+type strategyReadResult struct {
+	// strategies that can be used, either valid or the last known good strategy of an invalid tier
+	strategies map[string]*StrategyConfig
+	// invalid tiers without a last known good strategy
+	invalid map[string]error
+	// set if the config couldn't be parsed at all
+	parseErr error
+}
+
+func NewConfigManager(cm string, namespace string, client client.Reader) *ConfigManager {
 	if cm == "" {
 		cm = DefaultConfigMapName
 	}
@@ -39,28 +105,255 @@ func NewConfigManager(cm string, namespace string, client client.Client) *Config
 		configMapName:      cm,
 		configMapNamespace: namespace,
 		client:             client,
+		results:            map[providers.ResourceType]*strategyReadResult{},
+		versions:           map[providers.ResourceType]string{},
+		lastKnownGood:      map[providers.ResourceType]map[string]*StrategyConfig{},
+		lastEvents:         map[string]bool{},
 	}
 }
 
-func NewDefaultConfigManager(client client.Client) *ConfigManager {
+func NewDefaultConfigManager(client client.Reader) *ConfigManager {
 	return NewConfigManager(DefaultConfigMapName, DefaultConfigMapNamespace, client)
 }
 
+// ReadBlobStorageStrategy Read the strategy for a blob storage tier, errors caused by the tier not being defined or
+// being invalid have providers.ErrTierNotFound or providers.ErrInvalidStrategy as their cause
 func (m *ConfigManager) ReadBlobStorageStrategy(ctx context.Context, tier string) (*StrategyConfig, error) {
-	cm := &v1.ConfigMap{}
-	err := m.client.Get(ctx, types.NamespacedName{Name: m.configMapName, Namespace: m.configMapNamespace}, cm)
+	res, err := m.readStrategies(ctx, providers.BlobStorageResourceType)
+	if err != nil {
+		return nil, err
+	}
+	return res.tierStrategy(providers.BlobStorageResourceType, tier)
+}
+
+func (r *strategyReadResult) tierStrategy(rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+	if tierStrat, ok := r.strategies[tier]; ok {
+		// return a copy, strategies are kept as the last known good config
+		stratCfg := *tierStrat
+		return &stratCfg, nil
+	}
+	if err, ok := r.invalid[tier]; ok {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidStrategy, "aws %s strategy for tier %s is invalid, %s", rt, tier, err.Error())
+	}
+	if r.parseErr != nil {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidStrategy, "aws %s strategy config is invalid, %s", rt, r.parseErr.Error())
+	}
+	return nil, errorUtil.Wrapf(providers.ErrTierNotFound, "aws %s strategy for tier %s is not defined", rt, tier)
+}
+
+// readStrategies Read and validate the strategies for all tiers of a resource type, tiers defined in the
+// CloudResourceConfig take precedence over tiers defined in the legacy strategy configmap. Invalid tiers fall back to
+// their last known good strategy and are reported as an event on the object they were read from. The strategies are
+// only parsed again when the objects they're read from have changed, subscribers are notified of changed tiers
+func (m *ConfigManager) readStrategies(ctx context.Context, rt providers.ResourceType) (*strategyReadResult, error) {
+	cm, err := providers.GetConfigMap(ctx, m.client, m.configMapName, m.configMapNamespace)
 	if err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to get aws strategy config map %s in namespace %s", m.configMapName, m.configMapNamespace)
+		return nil, errorUtil.Wrap(err, "failed to read aws strategy config")
+	}
+	crc, err := providers.GetCloudResourceConfig(ctx, m.client)
+	if err != nil {
+		return nil, err
+	}
+	version := providers.ConfigVersion(cm, crc)
+
+	m.mu.Lock()
+	if version != "" && version == m.versions[rt] {
+		defer m.mu.Unlock()
+		return m.results[rt], nil
+	}
+	res := &strategyReadResult{
+		strategies: map[string]*StrategyConfig{},
+		invalid:    map[string]error{},
+	}
+	validate := strategyValidators[rt]
+	var events []invalidStrategyEvent
+	if cm != nil && cm.Data[string(rt)] != "" {
+		var tiers map[string]*StrategyConfig
+		if tiers, res.parseErr = parseStrategies([]byte(cm.Data[string(rt)]), res); res.parseErr != nil {
+			events = append(events, invalidStrategyEvent{obj: cm, msg: fmt.Sprintf("aws %s strategy config is invalid: %s", rt, res.parseErr.Error())})
+		}
+		strategies, invalid := ResolveStrategies(tiers, validate)
+		for tier, stratCfg := range strategies {
+			res.strategies[tier] = stratCfg
+		}
+		for tier, err := range invalid {
+			res.invalid[tier] = err
+		}
+		for tier, err := range res.invalid {
+			events = append(events, invalidStrategyEvent{obj: cm, msg: fmt.Sprintf("aws %s strategy for tier %s is invalid: %s", rt, tier, err.Error())})
+		}
+	}
+	if crc != nil && crc.Spec.Providers.AWS != nil {
+		tiers := map[string]*StrategyConfig{}
+		for tier, s := range crdStrategies(crc.Spec.Providers.AWS, rt) {
+			tiers[tier] = NewStrategyConfig(s)
+			// tiers defined in the CloudResourceConfig replace tiers of the same name, including base tiers
+			delete(res.strategies, tier)
+			delete(res.invalid, tier)
+		}
+		strategies, invalid := ResolveStrategies(tiers, validate)
+		for tier, stratCfg := range strategies {
+			res.strategies[tier] = stratCfg
+		}
+		for tier, err := range invalid {
+			res.invalid[tier] = err
+			events = append(events, invalidStrategyEvent{obj: crc, msg: fmt.Sprintf("aws %s strategy for tier %s is invalid: %s", rt, tier, err.Error())})
+		}
+	}
+
+	m.recordInvalid(events)
+	for tier, lkg := range m.lastKnownGood[rt] {
+		_, isInvalid := res.invalid[tier]
+		_, isValid := res.strategies[tier]
+		if isInvalid || (res.parseErr != nil && !isValid) {
+			log.Info("Using last known good strategy for invalid tier", "ResourceType", rt, "Tier", tier)
+			res.strategies[tier] = lkg
+			delete(res.invalid, tier)
+		}
 	}
-	rawStrategyCfg := cm.Data[string(providers.BlobStorageResourceType)]
-	if rawStrategyCfg == "" {
-		return nil, errorUtil.New(fmt.Sprintf("aws strategy for resource type %s is not defined", providers.BlobStorageResourceType))
+	prev := m.results[rt]
+	m.lastKnownGood[rt] = res.strategies
+	m.results[rt] = res
+	m.versions[rt] = version
+	m.mu.Unlock()
+
+	if prev != nil {
+		if changed := changedTiers(prev, res); len(changed) > 0 {
+			m.Notify(providers.ConfigChange{Tiers: map[providers.ResourceType][]string{rt: changed}})
+		}
+	}
+	return res, nil
+}
+
+// Watch Read the strategies of every resource type read so far again whenever the objects they're read from change,
+// so subscribers are notified without waiting for the next read
+func (m *ConfigManager) Watch(w *providers.ConfigWatcher) {
+	w.AddHandler(func(obj runtime.Object) {
+		if !providers.IsConfigSource(obj, m.configMapName, m.configMapNamespace) {
+			return
+		}
+		m.mu.Lock()
+		var rts []providers.ResourceType
+		for rt := range m.results {
+			rts = append(rts, rt)
+		}
+		m.mu.Unlock()
+		for _, rt := range rts {
+			if _, err := m.readStrategies(context.TODO(), rt); err != nil {
+				log.Error(err, "failed to read aws strategy config after it changed", "ResourceType", rt)
+			}
+		}
+	})
+}
+
+// changedTiers Get the tiers that were added, removed, changed or became invalid between two reads
+func changedTiers(prev, next *strategyReadResult) []string {
+	changed := map[string]bool{}
+	for tier, stratCfg := range next.strategies {
+		if prevStratCfg, ok := prev.strategies[tier]; !ok || !stratCfg.equal(prevStratCfg) {
+			changed[tier] = true
+		}
+	}
+	for tier := range prev.strategies {
+		if _, ok := next.strategies[tier]; !ok {
+			changed[tier] = true
+		}
+	}
+	return providers.SortedKeys(changed)
+}
+
+func (s *StrategyConfig) equal(o *StrategyConfig) bool {
+	return s.Region == o.Region && bytes.Equal(s.RawStrategy, o.RawStrategy) && reflect.DeepEqual(s.Overridable, o.Overridable) &&
+		s.Endpoint == o.Endpoint && s.ForcePathStyle == o.ForcePathStyle && s.DisableSSL == o.DisableSSL && s.CABundle == o.CABundle &&
+		reflect.DeepEqual(s.CORSRules, o.CORSRules) && reflect.DeepEqual(s.PolicyStatements, o.PolicyStatements) && reflect.DeepEqual(s.AllowPublicPolicy, o.AllowPublicPolicy)
+}
+
+// parseStrategies Parse the strategies for all tiers of a resource type, an error is only returned if the config can't
+// be parsed at all, tiers that can't be parsed are added to the invalid tiers of res
+func parseStrategies(raw []byte, res *strategyReadResult) (map[string]*StrategyConfig, error) {
+	var rawStrategies map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rawStrategies); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to unmarshal strategy mapping")
+	}
+	tiers := map[string]*StrategyConfig{}
+	for tier, rawTierStrat := range rawStrategies {
+		stratCfg := &StrategyConfig{}
+		if err := strictUnmarshal(rawTierStrat, stratCfg); err != nil {
+			res.invalid[tier] = err
+			continue
+		}
+		tiers[tier] = stratCfg
 	}
+	return tiers, nil
+}
+
+// recordInvalid Record a warning event for each invalid strategy, events are only recorded once while the strategy
+// stays invalid. Must be called while holding the lock
+func (m *ConfigManager) recordInvalid(events []invalidStrategyEvent) {
+	recorded := map[string]bool{}
+	for _, e := range events {
+		recorded[e.msg] = true
+		if m.lastEvents[e.msg] {
+			continue
+		}
+		log.Info(e.msg)
+		if m.Recorder != nil {
+			m.Recorder.Event(e.obj, v1.EventTypeWarning, "InvalidStrategy", e.msg)
+		}
+	}
+	m.lastEvents = recorded
+}
+
+// crdStrategies Get the tiers defined in the CloudResourceConfig for a resource type
+func crdStrategies(cfg *v1alpha1.AWSProviderConfig, rt providers.ResourceType) map[string]v1alpha1.AWSStrategy {
+	switch rt {
+	case providers.BlobStorageResourceType:
+		return cfg.BlobStorage
+	}
+	return nil
+}
 
-	var strategies map[string]*StrategyConfig
-	if err = json.Unmarshal([]byte(rawStrategyCfg), &strategies); err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to unmarshal strategy mapping for resource type %s", providers.BlobStorageResourceType)
+// NewStrategyConfig Convert a tier defined in the CloudResourceConfig to a strategy config
+func NewStrategyConfig(s v1alpha1.AWSStrategy) *StrategyConfig {
+	rawStrategy := json.RawMessage(s.Strategy.Raw)
+	if len(rawStrategy) == 0 {
+		rawStrategy = json.RawMessage("{}")
 	}
-	tierStrat := strategies[tier]
-	return tierStrat, nil
+	var overrides map[string]json.RawMessage
+	if len(s.RegionOverrides) > 0 {
+		overrides = map[string]json.RawMessage{}
+		for region, o := range s.RegionOverrides {
+			overrides[region] = json.RawMessage(o.Raw)
+		}
+	}
+	// an empty list of statements is kept, it removes the policy of buckets rather than leaving it alone
+	var statements []json.RawMessage
+	if s.PolicyStatements != nil {
+		statements = make([]json.RawMessage, 0, len(s.PolicyStatements))
+	}
+	for _, st := range s.PolicyStatements {
+		statements = append(statements, json.RawMessage(st.Raw))
+	}
+	return &StrategyConfig{
+		Region:            s.Region,
+		RawStrategy:       rawStrategy,
+		Extends:           s.Extends,
+		Base:              s.Base,
+		RegionOverrides:   overrides,
+		Overridable:       s.Overridable,
+		Endpoint:          s.Endpoint,
+		ForcePathStyle:    s.ForcePathStyle,
+		DisableSSL:        s.DisableSSL,
+		CABundle:          s.CABundle,
+		CORSRules:         s.CORSRules,
+		PolicyStatements:  statements,
+		AllowPublicPolicy: s.AllowPublicPolicy,
+	}
+}
+
+// strictUnmarshal Unmarshal data into v, failing on fields v doesn't define so typos in strategies are caught
+func strictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
 }
@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -94,6 +100,78 @@ func TestConfigManager_ReadBlobStorageStrategy(t *testing.T) {
 			}),
 		},
 	}
+	crdScheme := runtime.NewScheme()
+	if err = v1.AddToScheme(crdScheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err = v1alpha1.SchemeBuilder.AddToScheme(crdScheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	crdTiers := map[string]v1alpha1.AWSStrategy{
+		"test": {
+			Region: "us-east-1",
+			Strategy: runtime.RawExtension{
+				Raw: []byte("{\"bucket\":\"crdbucket\"}"),
+			},
+		},
+		"nostrategy": {
+			Region: "us-east-1",
+		},
+	}
+	cases = append(cases, []struct {
+		name                string
+		cmName              string
+		cmNamespace         string
+		tier                string
+		expectedRegion      string
+		expectedRawStrategy string
+		client              client.Client
+	}{
+		{
+			name:                "test tier defined in cloud resource config takes precedence",
+			cmName:              "test",
+			cmNamespace:         "test",
+			tier:                "test",
+			expectedRegion:      "us-east-1",
+			expectedRawStrategy: "{\"bucket\":\"crdbucket\"}",
+			client: fake.NewFakeClientWithScheme(crdScheme, &v1.ConfigMap{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+				Data: map[string]string{
+					"blobstorage": fmt.Sprintf("{\"test\": %s}", string(rawStratCfg)),
+				},
+			}, &v1alpha1.CloudResourceConfig{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name: providers.DefaultCloudResourceConfigName,
+				},
+				Spec: v1alpha1.CloudResourceConfigSpec{
+					Providers: v1alpha1.ProvidersConfig{
+						AWS: &v1alpha1.AWSProviderConfig{BlobStorage: crdTiers},
+					},
+				},
+			}),
+		},
+		{
+			name:                "test empty strategy in cloud resource config is defaulted without legacy configmap",
+			cmName:              "test",
+			cmNamespace:         "test",
+			tier:                "nostrategy",
+			expectedRegion:      "us-east-1",
+			expectedRawStrategy: "{}",
+			client: fake.NewFakeClientWithScheme(crdScheme, &v1alpha1.CloudResourceConfig{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name: providers.DefaultCloudResourceConfigName,
+				},
+				Spec: v1alpha1.CloudResourceConfigSpec{
+					Providers: v1alpha1.ProvidersConfig{
+						AWS: &v1alpha1.AWSProviderConfig{BlobStorage: crdTiers},
+					},
+				},
+			}),
+		},
+	}...)
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			cm := NewConfigManager(tc.cmName, tc.cmNamespace, tc.client)
@@ -110,3 +188,241 @@ func TestConfigManager_ReadBlobStorageStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigManager_ReadBlobStorageStrategyValidation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	buildConfigMap := func(rawStrategies string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      "test",
+				Namespace: "test",
+			},
+			Data: map[string]string{
+				"blobstorage": rawStrategies,
+			},
+		}
+	}
+	validStrategies := "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {\"bucket\": \"testbucket\"}}}"
+	cases := []struct {
+		name           string
+		lastKnownGood  string
+		strategies     string
+		tier           string
+		expectedCause  error
+		expectedRegion string
+		expectedEvents int
+	}{
+		{
+			name:          "test tier not found error is returned for undefined tier",
+			strategies:    validStrategies,
+			tier:          "missing",
+			expectedCause: providers.ErrTierNotFound,
+		},
+		{
+			name:           "test invalid strategy error is returned for strategy with unknown field",
+			strategies:     "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {\"buckett\": \"testbucket\"}}}",
+			tier:           "test",
+			expectedCause:  providers.ErrInvalidStrategy,
+			expectedEvents: 1,
+		},
+		{
+			name:           "test invalid strategy error is returned for tier with unknown field",
+			strategies:     "{\"test\": {\"regoin\": \"eu-west-1\", \"strategy\": {}}}",
+			tier:           "test",
+			expectedCause:  providers.ErrInvalidStrategy,
+			expectedEvents: 1,
+		},
+		{
+			name:           "test invalid strategy error is returned when config can't be parsed",
+			strategies:     "{\"test\": ",
+			tier:           "test",
+			expectedCause:  providers.ErrInvalidStrategy,
+			expectedEvents: 1,
+		},
+		{
+			name:           "test valid tiers are used when another tier is invalid",
+			strategies:     "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {}}, \"other\": {\"strategy\": {\"ACL\": true}}}",
+			tier:           "test",
+			expectedRegion: "eu-west-1",
+			expectedEvents: 1,
+		},
+		{
+			name:           "test last known good strategy is used when tier becomes invalid",
+			lastKnownGood:  validStrategies,
+			strategies:     "{\"test\": {\"region\": \"us-east-1\", \"strategy\": {\"buckett\": \"testbucket\"}}}",
+			tier:           "test",
+			expectedRegion: "eu-west-1",
+			expectedEvents: 1,
+		},
+		{
+			name:           "test last known good strategy is used when config can't be parsed",
+			lastKnownGood:  validStrategies,
+			strategies:     "{\"test\": ",
+			tier:           "test",
+			expectedRegion: "eu-west-1",
+			expectedEvents: 1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			if tc.lastKnownGood != "" {
+				cm := NewConfigManager("test", "test", fake.NewFakeClientWithScheme(scheme, buildConfigMap(tc.lastKnownGood)))
+				if _, err := cm.ReadBlobStorageStrategy(context.TODO(), tc.tier); err != nil {
+					t.Fatal("failed to read last known good strategy", err)
+				}
+				cm.client = fake.NewFakeClientWithScheme(scheme, buildConfigMap(tc.strategies))
+				cm.Recorder = recorder
+				testReadBlobStorageStrategy(t, cm, tc.tier, tc.expectedCause, tc.expectedRegion)
+			} else {
+				cm := NewConfigManager("test", "test", fake.NewFakeClientWithScheme(scheme, buildConfigMap(tc.strategies)))
+				cm.Recorder = recorder
+				testReadBlobStorageStrategy(t, cm, tc.tier, tc.expectedCause, tc.expectedRegion)
+				// events are only recorded once while the config stays invalid
+				testReadBlobStorageStrategy(t, cm, tc.tier, tc.expectedCause, tc.expectedRegion)
+			}
+			if len(recorder.Events) != tc.expectedEvents {
+				t.Fatalf("unexpected number of events, expected %d but got %d", tc.expectedEvents, len(recorder.Events))
+			}
+			for i := 0; i < tc.expectedEvents; i++ {
+				if e := <-recorder.Events; !strings.HasPrefix(e, "Warning InvalidStrategy ") {
+					t.Fatalf("unexpected event, expected warning with reason InvalidStrategy but got %s", e)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigManager_ReadBlobStorageStrategyRecordsCloudResourceConfigEvent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := v1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	crc := &v1alpha1.CloudResourceConfig{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: providers.DefaultCloudResourceConfigName,
+		},
+		Spec: v1alpha1.CloudResourceConfigSpec{
+			Providers: v1alpha1.ProvidersConfig{
+				AWS: &v1alpha1.AWSProviderConfig{
+					BlobStorage: map[string]v1alpha1.AWSStrategy{
+						"test": {Region: "eu-west-1", Strategy: runtime.RawExtension{Raw: []byte("{\"buckett\": \"testbucket\"}")}},
+					},
+				},
+			},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	cm := NewConfigManager("test", "test", fake.NewFakeClientWithScheme(scheme, crc))
+	cm.Recorder = recorder
+
+	testReadBlobStorageStrategy(t, cm, "test", providers.ErrInvalidStrategy, "")
+	if len(recorder.Events) != 1 {
+		t.Fatalf("unexpected number of events, expected 1 but got %d", len(recorder.Events))
+	}
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Warning InvalidStrategy ") || !strings.Contains(e, "test") {
+		t.Fatalf("unexpected event, expected warning with reason InvalidStrategy for tier test but got %s", e)
+	}
+}
+
+func testReadBlobStorageStrategy(t *testing.T, cm *ConfigManager, tier string, expectedCause error, expectedRegion string) {
+	sc, err := cm.ReadBlobStorageStrategy(context.TODO(), tier)
+	if expectedCause != nil {
+		if errorUtil.Cause(err) != expectedCause {
+			t.Fatalf("unexpected error cause, expected %v but got %v", expectedCause, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if sc.Region != expectedRegion {
+		t.Fatalf("unexpected region, expected %s but got %s", expectedRegion, sc.Region)
+	}
+}
+
+func TestConfigManager_ReadBlobStorageStrategyNotifiesChanges(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	buildConfigMap := func(resourceVersion string, rawStrategies string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:            "test",
+				Namespace:       "test",
+				ResourceVersion: resourceVersion,
+			},
+			Data: map[string]string{
+				"blobstorage": rawStrategies,
+			},
+		}
+	}
+	cases := []struct {
+		name            string
+		initial         *v1.ConfigMap
+		updated         *v1.ConfigMap
+		expectedChanges []string
+	}{
+		{
+			name:            "test subscribers are notified of changed tiers",
+			initial:         buildConfigMap("1", "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {}}, \"other\": {\"region\": \"eu-west-1\", \"strategy\": {}}}"),
+			updated:         buildConfigMap("2", "{\"test\": {\"region\": \"us-east-1\", \"strategy\": {}}, \"other\": {\"region\": \"eu-west-1\", \"strategy\": {}}}"),
+			expectedChanges: []string{"test"},
+		},
+		{
+			name:            "test subscribers are notified of removed tiers",
+			initial:         buildConfigMap("1", "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {}}, \"other\": {\"region\": \"eu-west-1\", \"strategy\": {}}}"),
+			updated:         buildConfigMap("2", "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {}}}"),
+			expectedChanges: []string{"other"},
+		},
+		{
+			name:    "test subscribers are not notified when invalid tier falls back to last known good strategy",
+			initial: buildConfigMap("1", "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {}}}"),
+			updated: buildConfigMap("2", "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {\"buckett\": \"test\"}}}"),
+		},
+		{
+			name:    "test subscribers are not notified when resource version is unchanged",
+			initial: buildConfigMap("1", "{\"test\": {\"region\": \"eu-west-1\", \"strategy\": {}}}"),
+			updated: buildConfigMap("1", "{\"test\": {\"region\": \"us-east-1\", \"strategy\": {}}}"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := fake.NewFakeClientWithScheme(scheme, tc.initial)
+			cm := NewConfigManager("test", "test", fakeClient)
+			var changes []providers.ConfigChange
+			cm.Subscribe(func(change providers.ConfigChange) {
+				changes = append(changes, change)
+			})
+			if _, err := cm.ReadBlobStorageStrategy(context.TODO(), "test"); err != nil {
+				t.Fatal("failed to read initial strategy", err)
+			}
+			if err := fakeClient.Update(context.TODO(), tc.updated); err != nil {
+				t.Fatal("failed to update config map", err)
+			}
+			if _, err := cm.ReadBlobStorageStrategy(context.TODO(), "test"); err != nil {
+				t.Fatal("failed to read updated strategy", err)
+			}
+			if tc.expectedChanges == nil {
+				if len(changes) != 0 {
+					t.Fatalf("unexpected notification, got %v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 {
+				t.Fatalf("unexpected number of notifications, expected 1 but got %d", len(changes))
+			}
+			if !reflect.DeepEqual(changes[0].Tiers[providers.BlobStorageResourceType], tc.expectedChanges) {
+				t.Fatalf("unexpected changed tiers, expected %v but got %v", tc.expectedChanges, changes[0].Tiers)
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/metrics"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 	v1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
 	errorUtil "github.com/pkg/errors"
 	v12 "k8s.io/api/core/v1"
@@ -37,6 +39,15 @@ var (
 				"s3:DeleteBucket",
 				"s3:ListBucket",
 				"s3:ListAllMyBuckets",
+				"s3:GetBucketLocation",
+				"s3:GetBucketAcl",
+				"s3:PutBucketAcl",
+				"s3:GetBucketObjectLockConfiguration",
+				"s3:GetBucketCORS",
+				"s3:PutBucketCORS",
+				"s3:GetBucketPolicy",
+				"s3:PutBucketPolicy",
+				"s3:DeleteBucketPolicy",
 			},
 			Resource: "arn:aws:s3:::*",
 		},
@@ -101,6 +112,7 @@ func (m *CredentialManager) ReconcileCredentials(ctx context.Context, name strin
 	if err != nil {
 		return nil, nil, errorUtil.Wrapf(err, "failed to reconcile aws credential request %s", name)
 	}
+	waitStart := time.Now()
 	err = wait.PollImmediate(time.Second*5, time.Minute*5, func() (done bool, err error) {
 		if err = m.Client.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, cr); err != nil {
 			if errors.IsNotFound(err) {
@@ -110,6 +122,7 @@ func (m *CredentialManager) ReconcileCredentials(ctx context.Context, name strin
 		}
 		return cr.Status.Provisioned, nil
 	})
+	metrics.CredentialProvisioningWait.WithLabelValues(providers.AWSDeploymentStrategy).Observe(time.Since(waitStart).Seconds())
 	if err != nil {
 		return nil, nil, errorUtil.Wrap(err, "timed out waiting for credential request to become provisioned")
 	}
@@ -120,6 +133,43 @@ func (m *CredentialManager) ReconcileCredentials(ctx context.Context, name strin
 	return cr, awsCreds, nil
 }
 
+// GetProvisionedCredentials Get the credentials of a credential request without creating it, nil is returned if the
+// request doesn't exist or hasn't been provisioned yet
+func (m *CredentialManager) GetProvisionedCredentials(ctx context.Context, name string, ns string) (*AWSCredentials, error) {
+	cr := &v1.CredentialsRequest{}
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, cr); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get aws credential request %s", name)
+	}
+	if !cr.Status.Provisioned {
+		return nil, nil
+	}
+	return m.reconcileAWSCredentials(ctx, cr)
+}
+
+// CredentialsRequestExists Check whether a credential request has been created
+func (m *CredentialManager) CredentialsRequestExists(ctx context.Context, name string, ns string) (bool, error) {
+	cr, err := m.GetCredentialsRequest(ctx, name, ns)
+	if err != nil {
+		return false, err
+	}
+	return cr != nil, nil
+}
+
+// GetCredentialsRequest Get a credential request without creating it, nil is returned if it doesn't exist
+func (m *CredentialManager) GetCredentialsRequest(ctx context.Context, name string, ns string) (*v1.CredentialsRequest, error) {
+	cr := &v1.CredentialsRequest{}
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, cr); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get aws credential request %s", name)
+	}
+	return cr, nil
+}
+
 func (m *CredentialManager) reconcileCredentialRequest(ctx context.Context, name string, ns string, entries []v1.StatementEntry) (*v1.CredentialsRequest, error) {
 	codec, err := v1.NewCodec()
 	if err != nil {
@@ -0,0 +1,163 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/errcodes"
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	driftFieldRegion     = "region"
+	driftFieldObjectLock = "objectLockEnabled"
+	driftFieldACL        = "acl"
+
+	granteeAllUsers           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	granteeAuthenticatedUsers = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// cannedACLGrants The grants to groups each canned acl results in, besides full control for the bucket owner
+var cannedACLGrants = map[string][]string{
+	s3.BucketCannedACLPrivate:           {},
+	s3.BucketCannedACLPublicRead:        {granteeAllUsers + ":" + s3.PermissionRead},
+	s3.BucketCannedACLPublicReadWrite:   {granteeAllUsers + ":" + s3.PermissionRead, granteeAllUsers + ":" + s3.PermissionWrite},
+	s3.BucketCannedACLAuthenticatedRead: {granteeAuthenticatedUsers + ":" + s3.PermissionRead},
+}
+
+// bucketState The settings of an existing bucket that can be compared with its strategy
+type bucketState struct {
+	Region            string
+	ObjectLockEnabled bool
+	// grants other than those to the bucket owner, as grantee:permission
+	Grants []string
+}
+
+// describeBucket Read the settings of an existing bucket
+func describeBucket(s3svc s3iface.S3API, bucket string) (*bucketState, error) {
+	state := &bucketState{}
+	locOutput, err := s3svc.GetBucketLocation(&s3.GetBucketLocationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get location of s3 bucket %s", bucket)
+	}
+	state.Region = normalizeBucketRegion(aws.StringValue(locOutput.LocationConstraint))
+
+	lockOutput, err := s3svc.GetObjectLockConfiguration(&s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != errcodes.ObjectLockConfigurationNotFound {
+			return nil, errorUtil.Wrapf(err, "failed to get object lock configuration of s3 bucket %s", bucket)
+		}
+	} else if lockOutput.ObjectLockConfiguration != nil {
+		state.ObjectLockEnabled = aws.StringValue(lockOutput.ObjectLockConfiguration.ObjectLockEnabled) == s3.ObjectLockEnabledEnabled
+	}
+
+	aclOutput, err := s3svc.GetBucketAcl(&s3.GetBucketAclInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get acl of s3 bucket %s", bucket)
+	}
+	var ownerID string
+	if aclOutput.Owner != nil {
+		ownerID = aws.StringValue(aclOutput.Owner.ID)
+	}
+	for _, g := range aclOutput.Grants {
+		if g.Grantee == nil {
+			continue
+		}
+		if aws.StringValue(g.Grantee.Type) == s3.TypeCanonicalUser && aws.StringValue(g.Grantee.ID) == ownerID {
+			continue
+		}
+		grantee := aws.StringValue(g.Grantee.URI)
+		if grantee == "" {
+			grantee = aws.StringValue(g.Grantee.ID)
+		}
+		state.Grants = append(state.Grants, grantee+":"+aws.StringValue(g.Permission))
+	}
+	sort.Strings(state.Grants)
+	return state, nil
+}
+
+// compareBucket Find the settings of a bucket that differ from the input it should have been created with
+func compareBucket(desired *s3.CreateBucketInput, region string, actual *bucketState) []providers.Drift {
+	var drift []providers.Drift
+	if desired.CreateBucketConfiguration != nil && aws.StringValue(desired.CreateBucketConfiguration.LocationConstraint) != "" {
+		region = aws.StringValue(desired.CreateBucketConfiguration.LocationConstraint)
+	}
+	if region = normalizeBucketRegion(region); region != actual.Region {
+		drift = append(drift, providers.Drift{Field: driftFieldRegion, Desired: region, Actual: actual.Region})
+	}
+	if desiredLock := aws.BoolValue(desired.ObjectLockEnabledForBucket); desiredLock != actual.ObjectLockEnabled {
+		drift = append(drift, providers.Drift{Field: driftFieldObjectLock, Desired: strconv.FormatBool(desiredLock), Actual: strconv.FormatBool(actual.ObjectLockEnabled)})
+	}
+	if desiredGrants, ok := cannedACLGrants[aws.StringValue(desired.ACL)]; ok {
+		sorted := append([]string{}, desiredGrants...)
+		sort.Strings(sorted)
+		if strings.Join(sorted, ",") != strings.Join(actual.Grants, ",") {
+			drift = append(drift, providers.Drift{Field: driftFieldACL, Desired: aws.StringValue(desired.ACL), Actual: fmt.Sprintf("[%s]", strings.Join(actual.Grants, ", "))})
+		}
+	}
+	return drift
+}
+
+// compareStorage Describe the settings, cors rules and policy of an existing bucket and find those that differ from its
+// strategy, managed are the settings managed before that are removed if the strategy no longer sets them
+func compareStorage(s3svc s3iface.S3API, desired *s3.CreateBucketInput, stratCfg *StrategyConfig, managed []string) ([]providers.Drift, error) {
+	bucket := aws.StringValue(desired.Bucket)
+	actual, err := describeBucket(s3svc, bucket)
+	if err != nil {
+		return nil, err
+	}
+	drift := compareBucket(desired, stratCfg.Region, actual)
+	desiredPolicies, err := desiredBucketPolicies(bucket, stratCfg, managed)
+	if err != nil {
+		return nil, err
+	}
+	actualPolicies, err := describeBucketPolicies(s3svc, bucket, desiredPolicies)
+	if err != nil {
+		return nil, err
+	}
+	return append(drift, compareBucketPolicies(desiredPolicies, actualPolicies)...), nil
+}
+
+// isFixable Check whether drift of a setting is corrected by CorrectDrift, the region and object lock of a bucket
+// can't be changed once it's created
+func isFixable(d providers.Drift) bool {
+	switch d.Field {
+	case driftFieldACL, driftFieldCORS, driftFieldPolicy:
+		return true
+	}
+	return false
+}
+
+// logCorrectedDrift Log the settings of the bucket of an instance that have been corrected
+func logCorrectedDrift(bs *v1alpha1.BlobStorage, drift []providers.Drift) {
+	for _, d := range drift {
+		if d.Fixed {
+			log.Info("Corrected drift of s3 bucket", "Request.Namespace", bs.Namespace, "Request.Name", bs.Name, "Field", d.Field, "Actual", d.Actual, "Desired", d.Desired)
+		}
+	}
+}
+
+// normalizeBucketRegion Convert a bucket location constraint to the region it refers to
+func normalizeBucketRegion(loc string) string {
+	switch loc {
+	case "":
+		return "us-east-1"
+	case s3.BucketLocationConstraintEu:
+		return "eu-west-1"
+	}
+	return loc
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestCompareBucket(t *testing.T) {
+	cases := []struct {
+		name          string
+		desired       *s3.CreateBucketInput
+		region        string
+		actual        *bucketState
+		expectedDrift []string
+	}{
+		{
+			name:    "test no drift is found when bucket matches strategy",
+			desired: &s3.CreateBucketInput{ACL: aws.String(s3.BucketCannedACLPrivate)},
+			region:  "eu-west-1",
+			actual:  &bucketState{Region: "eu-west-1"},
+		},
+		{
+			name: "test location constraint takes precedence over strategy region",
+			desired: &s3.CreateBucketInput{
+				CreateBucketConfiguration: &s3.CreateBucketConfiguration{LocationConstraint: aws.String("eu-central-1")},
+			},
+			region: "eu-west-1",
+			actual: &bucketState{Region: "eu-central-1"},
+		},
+		{
+			name:          "test region drift is found",
+			desired:       &s3.CreateBucketInput{},
+			region:        "eu-west-1",
+			actual:        &bucketState{Region: "us-east-1"},
+			expectedDrift: []string{driftFieldRegion},
+		},
+		{
+			name:          "test object lock drift is found",
+			desired:       &s3.CreateBucketInput{ObjectLockEnabledForBucket: aws.Bool(true)},
+			region:        "eu-west-1",
+			actual:        &bucketState{Region: "eu-west-1"},
+			expectedDrift: []string{driftFieldObjectLock},
+		},
+		{
+			name:          "test acl drift is found when bucket was made public",
+			desired:       &s3.CreateBucketInput{ACL: aws.String(s3.BucketCannedACLPrivate)},
+			region:        "eu-west-1",
+			actual:        &bucketState{Region: "eu-west-1", Grants: []string{granteeAllUsers + ":" + s3.PermissionRead}},
+			expectedDrift: []string{driftFieldACL},
+		},
+		{
+			name:    "test acl isn't compared when strategy doesn't set it",
+			desired: &s3.CreateBucketInput{},
+			region:  "eu-west-1",
+			actual:  &bucketState{Region: "eu-west-1", Grants: []string{granteeAllUsers + ":" + s3.PermissionRead}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			drift := compareBucket(tc.desired, tc.region, tc.actual)
+			if len(drift) != len(tc.expectedDrift) {
+				t.Fatalf("unexpected drift, expected %v but got %v", tc.expectedDrift, drift)
+			}
+			for i, d := range drift {
+				if d.Field != tc.expectedDrift[i] {
+					t.Fatalf("unexpected drift field, expected %s but got %s", tc.expectedDrift[i], d.Field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// Package errcodes defines the codes of aws errors handled by the aws providers that the aws sdk doesn't define, shared
+// with the fakes used to test them
+package errcodes
+
+const (
+	// ObjectLockConfigurationNotFound returned when getting the object lock configuration of a bucket without one
+	ObjectLockConfigurationNotFound = "ObjectLockConfigurationNotFoundError"
+	// NoSuchCORSConfiguration returned when getting the cors rules of a bucket without any
+	NoSuchCORSConfiguration = "NoSuchCORSConfiguration"
+	// NoSuchBucketPolicy returned when getting the policy of a bucket without one
+	NoSuchBucketPolicy = "NoSuchBucketPolicy"
+)
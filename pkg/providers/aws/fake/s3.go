@@ -0,0 +1,427 @@
+// Package fake provides in-memory implementations of the aws apis used by the aws providers, so provider behaviour can
+// be tested without calling aws
+package fake
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/errcodes"
+)
+
+const (
+	// ErrCodeBucketNotEmpty returned when deleting a bucket that still contains objects
+	ErrCodeBucketNotEmpty = "BucketNotEmpty"
+	// ErrCodeNotFound returned by head requests for buckets that don't exist
+	ErrCodeNotFound = "NotFound"
+	// ErrCodeResourceNotReady returned by waiters whose condition isn't met
+	ErrCodeResourceNotReady = "ResourceNotReady"
+
+	// OwnerID The canonical id of the account owning every bucket
+	OwnerID = "owner"
+
+	granteeAllUsers           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	granteeAuthenticatedUsers = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// Bucket An s3 bucket held by the fake
+type Bucket struct {
+	Region            string
+	ObjectLockEnabled bool
+	// ACL is the canned acl of the bucket, private if empty
+	ACL       string
+	CORSRules []*s3.CORSRule
+	// Policy is the policy document of the bucket, empty if it has none
+	Policy  string
+	Objects map[string][]byte
+}
+
+// S3 An in-memory s3 api modelling buckets, their objects and the errors aws returns for them. Operations the fake
+// doesn't implement panic when called
+type S3 struct {
+	s3iface.S3API
+
+	mu sync.Mutex
+	// Region is the region of the endpoint being called, buckets are created in it if their create input has no
+	// location constraint as the aws sdk sets the constraint to the region of the client
+	Region  string
+	buckets map[string]*Bucket
+	// Errors are returned instead of performing an operation, by operation name e.g. CreateBucket
+	Errors map[string]error
+	// Calls counts the calls made to each operation, by operation name
+	Calls map[string]int
+}
+
+var _ s3iface.S3API = &S3{}
+
+// NewS3 Create a fake s3 api without any buckets, buckets are created in region unless their input specifies one
+func NewS3(region string) *S3 {
+	return &S3{
+		Region:  region,
+		buckets: map[string]*Bucket{},
+		Errors:  map[string]error{},
+		Calls:   map[string]int{},
+	}
+}
+
+// AddBucket Add an existing bucket to the fake
+func (f *S3) AddBucket(name string, b *Bucket) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if b.Objects == nil {
+		b.Objects = map[string][]byte{}
+	}
+	f.buckets[name] = b
+}
+
+// Bucket Get a bucket held by the fake, nil is returned if it doesn't exist
+func (f *S3) Bucket(name string) *Bucket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buckets[name]
+}
+
+func (f *S3) ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListBuckets"); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range f.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	output := &s3.ListBucketsOutput{
+		Owner: &s3.Owner{ID: aws.String(OwnerID)},
+	}
+	for _, name := range names {
+		output.Buckets = append(output.Buckets, &s3.Bucket{Name: aws.String(name)})
+	}
+	return output, nil
+}
+
+func (f *S3) CreateBucket(input *s3.CreateBucketInput) (*s3.CreateBucketOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CreateBucket"); err != nil {
+		return nil, err
+	}
+	name := aws.StringValue(input.Bucket)
+	if _, ok := f.buckets[name]; ok {
+		return nil, awserr.New(s3.ErrCodeBucketAlreadyOwnedByYou, fmt.Sprintf("bucket %s already exists", name), nil)
+	}
+	region := f.Region
+	if input.CreateBucketConfiguration != nil && aws.StringValue(input.CreateBucketConfiguration.LocationConstraint) != "" {
+		region = aws.StringValue(input.CreateBucketConfiguration.LocationConstraint)
+	}
+	f.buckets[name] = &Bucket{
+		Region:            region,
+		ObjectLockEnabled: aws.BoolValue(input.ObjectLockEnabledForBucket),
+		ACL:               aws.StringValue(input.ACL),
+		Objects:           map[string][]byte{},
+	}
+	return &s3.CreateBucketOutput{Location: aws.String("/" + name)}, nil
+}
+
+func (f *S3) DeleteBucket(input *s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteBucket"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Objects) > 0 {
+		return nil, awserr.New(ErrCodeBucketNotEmpty, fmt.Sprintf("bucket %s is not empty", aws.StringValue(input.Bucket)), nil)
+	}
+	delete(f.buckets, aws.StringValue(input.Bucket))
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+func (f *S3) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("HeadBucket"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.buckets[aws.StringValue(input.Bucket)]; !ok {
+		return nil, awserr.New(ErrCodeNotFound, "Not Found", nil)
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+// WaitUntilBucketNotExists Return immediately, with an error if the bucket still exists
+func (f *S3) WaitUntilBucketNotExists(input *s3.HeadBucketInput) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("WaitUntilBucketNotExists"); err != nil {
+		return err
+	}
+	if _, ok := f.buckets[aws.StringValue(input.Bucket)]; ok {
+		return awserr.New(ErrCodeResourceNotReady, "exceeded wait attempts", nil)
+	}
+	return nil
+}
+
+func (f *S3) GetBucketLocation(input *s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetBucketLocation"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	output := &s3.GetBucketLocationOutput{}
+	// buckets in us-east-1 have no location constraint
+	if b.Region != "us-east-1" {
+		output.LocationConstraint = aws.String(b.Region)
+	}
+	return output, nil
+}
+
+func (f *S3) GetObjectLockConfiguration(input *s3.GetObjectLockConfigurationInput) (*s3.GetObjectLockConfigurationOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetObjectLockConfiguration"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	if !b.ObjectLockEnabled {
+		return nil, awserr.New(errcodes.ObjectLockConfigurationNotFound, "Object Lock configuration does not exist for this bucket", nil)
+	}
+	return &s3.GetObjectLockConfigurationOutput{
+		ObjectLockConfiguration: &s3.ObjectLockConfiguration{
+			ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+		},
+	}, nil
+}
+
+func (f *S3) GetBucketAcl(input *s3.GetBucketAclInput) (*s3.GetBucketAclOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetBucketAcl"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	grants := []*s3.Grant{
+		{
+			Grantee:    &s3.Grantee{Type: aws.String(s3.TypeCanonicalUser), ID: aws.String(OwnerID)},
+			Permission: aws.String(s3.PermissionFullControl),
+		},
+	}
+	groupGrant := func(uri, permission string) *s3.Grant {
+		return &s3.Grant{
+			Grantee:    &s3.Grantee{Type: aws.String(s3.TypeGroup), URI: aws.String(uri)},
+			Permission: aws.String(permission),
+		}
+	}
+	switch b.ACL {
+	case s3.BucketCannedACLPublicRead:
+		grants = append(grants, groupGrant(granteeAllUsers, s3.PermissionRead))
+	case s3.BucketCannedACLPublicReadWrite:
+		grants = append(grants, groupGrant(granteeAllUsers, s3.PermissionRead), groupGrant(granteeAllUsers, s3.PermissionWrite))
+	case s3.BucketCannedACLAuthenticatedRead:
+		grants = append(grants, groupGrant(granteeAuthenticatedUsers, s3.PermissionRead))
+	}
+	return &s3.GetBucketAclOutput{
+		Owner:  &s3.Owner{ID: aws.String(OwnerID)},
+		Grants: grants,
+	}, nil
+}
+
+func (f *S3) PutBucketAcl(input *s3.PutBucketAclInput) (*s3.PutBucketAclOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("PutBucketAcl"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	b.ACL = aws.StringValue(input.ACL)
+	return &s3.PutBucketAclOutput{}, nil
+}
+
+func (f *S3) GetBucketCors(input *s3.GetBucketCorsInput) (*s3.GetBucketCorsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetBucketCors"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	if len(b.CORSRules) == 0 {
+		return nil, awserr.New(errcodes.NoSuchCORSConfiguration, "The CORS configuration does not exist", nil)
+	}
+	return &s3.GetBucketCorsOutput{CORSRules: b.CORSRules}, nil
+}
+
+func (f *S3) PutBucketCors(input *s3.PutBucketCorsInput) (*s3.PutBucketCorsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("PutBucketCors"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	if input.CORSConfiguration != nil {
+		b.CORSRules = input.CORSConfiguration.CORSRules
+	}
+	return &s3.PutBucketCorsOutput{}, nil
+}
+
+func (f *S3) DeleteBucketCors(input *s3.DeleteBucketCorsInput) (*s3.DeleteBucketCorsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteBucketCors"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	b.CORSRules = nil
+	return &s3.DeleteBucketCorsOutput{}, nil
+}
+
+func (f *S3) GetBucketPolicy(input *s3.GetBucketPolicyInput) (*s3.GetBucketPolicyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetBucketPolicy"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	if b.Policy == "" {
+		return nil, awserr.New(errcodes.NoSuchBucketPolicy, "The bucket policy does not exist", nil)
+	}
+	return &s3.GetBucketPolicyOutput{Policy: aws.String(b.Policy)}, nil
+}
+
+func (f *S3) PutBucketPolicy(input *s3.PutBucketPolicyInput) (*s3.PutBucketPolicyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("PutBucketPolicy"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	b.Policy = aws.StringValue(input.Policy)
+	return &s3.PutBucketPolicyOutput{}, nil
+}
+
+func (f *S3) DeleteBucketPolicy(input *s3.DeleteBucketPolicyInput) (*s3.DeleteBucketPolicyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteBucketPolicy"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	b.Policy = ""
+	return &s3.DeleteBucketPolicyOutput{}, nil
+}
+
+func (f *S3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("PutObject"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	if input.Body != nil {
+		if body, err = ioutil.ReadAll(input.Body); err != nil {
+			return nil, err
+		}
+	}
+	b.Objects[aws.StringValue(input.Key)] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *S3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteObject"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	delete(b.Objects, aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *S3) ListObjects(input *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListObjects"); err != nil {
+		return nil, err
+	}
+	b, err := f.bucket(aws.StringValue(input.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key := range b.Objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	output := &s3.ListObjectsOutput{
+		Name: input.Bucket,
+	}
+	for _, key := range keys {
+		output.Contents = append(output.Contents, &s3.Object{
+			Key:  aws.String(key),
+			Size: aws.Int64(int64(len(b.Objects[key]))),
+		})
+	}
+	return output, nil
+}
+
+// call Count a call to an operation and get the error it should fail with. Must be called while holding the lock
+func (f *S3) call(operation string) error {
+	f.Calls[operation]++
+	return f.Errors[operation]
+}
+
+// bucket Get a bucket or the error aws returns if it doesn't exist. Must be called while holding the lock
+func (f *S3) bucket(name string) (*Bucket, error) {
+	b, ok := f.buckets[name]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchBucket, fmt.Sprintf("bucket %s does not exist", name), nil)
+	}
+	return b, nil
+}
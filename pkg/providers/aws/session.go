@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/metrics"
+	errorUtil "github.com/pkg/errors"
+)
+
+// errCodeUnknown is recorded for failed aws api calls that didn't return an aws error, e.g. network errors
+const errCodeUnknown = "Unknown"
+
+// S3ClientFactory Create an s3 client for the region and endpoint of a strategy using the credentials, replaced in
+// tests so aws isn't called. S3 is the only aws api the providers call, iam users and their keys are created by the
+// cloud credential operator from credentials requests, so no iam or rds client factories are needed
+type S3ClientFactory func(stratCfg *StrategyConfig, creds *AWSCredentials) (s3iface.S3API, error)
+
+// newS3Client Create an s3 client calling aws, or the s3-compatible store set as the endpoint of the strategy
+func newS3Client(stratCfg *StrategyConfig, creds *AWSCredentials) (s3iface.S3API, error) {
+	sess, err := newSession(stratCfg, creds)
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
+// newSession Create an aws session for the region and endpoint of a strategy using the credentials, the count,
+// latency and error code of every api call made with it are recorded as metrics
+func newSession(stratCfg *StrategyConfig, creds *AWSCredentials) (*session.Session, error) {
+	opts := session.Options{
+		Config: aws.Config{
+			Region:           aws.String(stratCfg.Region),
+			Credentials:      credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, ""),
+			S3ForcePathStyle: aws.Bool(stratCfg.ForcePathStyle),
+			DisableSSL:       aws.Bool(stratCfg.DisableSSL),
+		},
+	}
+	if stratCfg.Endpoint != "" {
+		opts.Config.Endpoint = aws.String(stratCfg.Endpoint)
+	}
+	if stratCfg.CABundle != "" {
+		opts.CustomCABundle = strings.NewReader(stratCfg.CABundle)
+	}
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create aws session")
+	}
+	sess.Handlers.Complete.PushBack(recordAPICall)
+	return sess, nil
+}
+
+// validateEndpoint Check the endpoint settings of a strategy can be used to create a session
+func validateEndpoint(stratCfg *StrategyConfig) error {
+	if stratCfg.Endpoint != "" {
+		u, err := url.Parse(stratCfg.Endpoint)
+		if err != nil {
+			return errorUtil.Wrapf(err, "failed to parse endpoint %s", stratCfg.Endpoint)
+		}
+		if u.Host == "" {
+			return errorUtil.New(fmt.Sprintf("endpoint %s must include a host", stratCfg.Endpoint))
+		}
+	}
+	if stratCfg.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(stratCfg.CABundle)) {
+		return errorUtil.New("ca bundle doesn't contain any PEM encoded certificates")
+	}
+	return nil
+}
+
+func recordAPICall(r *request.Request) {
+	var operation, code string
+	if r.Operation != nil {
+		operation = r.Operation.Name
+	}
+	if r.Error != nil {
+		code = errCodeUnknown
+		if awsErr, ok := r.Error.(awserr.Error); ok {
+			code = awsErr.Code()
+		}
+	}
+	metrics.AWSAPICalls.WithLabelValues(r.ClientInfo.ServiceName, operation, code).Inc()
+	metrics.AWSAPILatency.WithLabelValues(r.ClientInfo.ServiceName, operation).Observe(time.Since(r.Time).Seconds())
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestNewSession(t *testing.T) {
+	creds := &AWSCredentials{
+		AccessKeyID:     "testkey",
+		SecretAccessKey: "testsecret",
+	}
+	cases := []struct {
+		name             string
+		stratCfg         *StrategyConfig
+		expectError      bool
+		expectedEndpoint string
+		expectedPath     bool
+	}{
+		{
+			name:     "test aws is used without endpoint",
+			stratCfg: &StrategyConfig{Region: "eu-west-1"},
+		},
+		{
+			name: "test endpoint settings are used for s3-compatible store",
+			stratCfg: &StrategyConfig{
+				Region:         "us-east-1",
+				Endpoint:       "http://minio:9000",
+				ForcePathStyle: true,
+				DisableSSL:     true,
+			},
+			expectedEndpoint: "http://minio:9000",
+			expectedPath:     true,
+		},
+		{
+			name: "test error is returned for invalid ca bundle",
+			stratCfg: &StrategyConfig{
+				Region:   "us-east-1",
+				Endpoint: "https://rgw:8443",
+				CABundle: "not a certificate",
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sess, err := newSession(tc.stratCfg, creds)
+			if err != nil {
+				if tc.expectError {
+					return
+				}
+				t.Fatal("failed to create session", err)
+			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if endpoint := aws.StringValue(sess.Config.Endpoint); endpoint != tc.expectedEndpoint {
+				t.Fatalf("unexpected endpoint, expected %s but got %s", tc.expectedEndpoint, endpoint)
+			}
+			if pathStyle := aws.BoolValue(sess.Config.S3ForcePathStyle); pathStyle != tc.expectedPath {
+				t.Fatalf("unexpected path style, expected %t but got %t", tc.expectedPath, pathStyle)
+			}
+		})
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	cases := []struct {
+		name        string
+		stratCfg    *StrategyConfig
+		expectError bool
+	}{
+		{
+			name:     "test strategy without endpoint is valid",
+			stratCfg: &StrategyConfig{},
+		},
+		{
+			name:     "test endpoint url is valid",
+			stratCfg: &StrategyConfig{Endpoint: "https://rgw.example.com:8443"},
+		},
+		{
+			name:        "test endpoint without host is invalid",
+			stratCfg:    &StrategyConfig{Endpoint: "rgw"},
+			expectError: true,
+		},
+		{
+			name:        "test ca bundle without certificates is invalid",
+			stratCfg:    &StrategyConfig{Endpoint: "https://rgw.example.com", CABundle: "test"},
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateEndpoint(tc.stratCfg)
+			if tc.expectError && err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		})
+	}
+}
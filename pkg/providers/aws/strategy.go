@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+)
+
+// ResolveStrategies Resolve the effective strategy of every tier that isn't a base tier, tiers that can't be resolved
+// or fail validation are returned as invalid
+func ResolveStrategies(tiers map[string]*StrategyConfig, validate func(*StrategyConfig) error) (map[string]*StrategyConfig, map[string]error) {
+	resolved := map[string]*StrategyConfig{}
+	invalid := map[string]error{}
+	for tier, stratCfg := range tiers {
+		if stratCfg.Base {
+			continue
+		}
+		resolvedCfg, err := ResolveStrategy(tiers, tier)
+		if err != nil {
+			invalid[tier] = err
+			continue
+		}
+		if err = validate(resolvedCfg); err != nil {
+			invalid[tier] = err
+			continue
+		}
+		resolved[tier] = resolvedCfg
+	}
+	return resolved, invalid
+}
+
+// ResolveStrategy Resolve the effective strategy of a tier. The strategies of the tiers it extends are merged from the
+// root of the chain down to the tier using JSON merge patch, followed by the overrides each of them defines for the
+// resolved region in the same order
+func ResolveStrategy(tiers map[string]*StrategyConfig, tier string) (*StrategyConfig, error) {
+	var chain []*StrategyConfig
+	seen := map[string]bool{}
+	for name := tier; name != ""; name = tiers[name].Extends {
+		if seen[name] {
+			return nil, errorUtil.New(fmt.Sprintf("tier %s has a cycle in the tiers it extends at tier %s", tier, name))
+		}
+		seen[name] = true
+		if _, ok := tiers[name]; !ok {
+			return nil, errorUtil.New(fmt.Sprintf("tier %s extends tier %s which is not defined", tier, name))
+		}
+		chain = append([]*StrategyConfig{tiers[name]}, chain...)
+	}
+
+	resolved := &StrategyConfig{
+		RawStrategy: json.RawMessage("{}"),
+	}
+	for _, stratCfg := range chain {
+		if stratCfg.Region != "" {
+			resolved.Region = stratCfg.Region
+		}
+		if stratCfg.Overridable != nil {
+			resolved.Overridable = stratCfg.Overridable
+		}
+		if stratCfg.CORSRules != nil {
+			resolved.CORSRules = stratCfg.CORSRules
+		}
+		if stratCfg.PolicyStatements != nil {
+			resolved.PolicyStatements = stratCfg.PolicyStatements
+		}
+		if stratCfg.AllowPublicPolicy != nil {
+			resolved.AllowPublicPolicy = stratCfg.AllowPublicPolicy
+		}
+		// the endpoint settings are used together, from the last tier in the chain setting an endpoint
+		if stratCfg.Endpoint != "" {
+			resolved.Endpoint = stratCfg.Endpoint
+			resolved.ForcePathStyle = stratCfg.ForcePathStyle
+			resolved.DisableSSL = stratCfg.DisableSSL
+			resolved.CABundle = stratCfg.CABundle
+		}
+		if err := resolved.merge(stratCfg.RawStrategy); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to merge strategy of tier %s", tier)
+		}
+	}
+	region := resolved.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	for _, stratCfg := range chain {
+		if err := resolved.merge(stratCfg.RegionOverrides[region]); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to merge %s override of tier %s", region, tier)
+		}
+	}
+	return resolved, nil
+}
+
+// ResolveTier Resolve the effective strategy of a tier defined in a raw strategy mapping, as found in the strategy
+// configmap
+func ResolveTier(rawStrategies []byte, tier string) (*StrategyConfig, error) {
+	var rawTiers map[string]json.RawMessage
+	if err := json.Unmarshal(rawStrategies, &rawTiers); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to unmarshal strategy mapping")
+	}
+	tiers := map[string]*StrategyConfig{}
+	for name, rawTierStrat := range rawTiers {
+		stratCfg := &StrategyConfig{}
+		if err := strictUnmarshal(rawTierStrat, stratCfg); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to unmarshal strategy for tier %s", name)
+		}
+		tiers[name] = stratCfg
+	}
+	if _, ok := tiers[tier]; !ok {
+		return nil, errorUtil.New(fmt.Sprintf("tier %s is not defined", tier))
+	}
+	return ResolveStrategy(tiers, tier)
+}
+
+// ApplyOverrides Merge the overrides of a resource onto the resolved strategy of its tier, errors caused by the
+// overrides setting fields the tier doesn't declare overridable have providers.ErrInvalidOverrides as their cause.
+// Only fields of the create bucket input can be overridden, settings applied after a bucket is created such as
+// versioning aren't supported. An overridden location constraint also sets the region of the strategy, so the bucket
+// is created in and compared with the region it's constrained to
+func ApplyOverrides(stratCfg *StrategyConfig, overrides []byte) (*StrategyConfig, error) {
+	if len(overrides) == 0 {
+		return stratCfg, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(overrides, &fields); err != nil {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidOverrides, "overrides must be a json object, %s", err.Error())
+	}
+	if disallowed := disallowedOverrides("", fields, stratCfg.Overridable); len(disallowed) > 0 {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidOverrides, "fields %s can't be overridden, the tier allows overriding [%s]", strings.Join(disallowed, ", "), strings.Join(stratCfg.Overridable, ", "))
+	}
+	overridden := *stratCfg
+	if err := overridden.merge(overrides); err != nil {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidOverrides, "failed to merge overrides, %s", err.Error())
+	}
+	s3cbi, err := buildCreateBucketInput(&overridden)
+	if err != nil {
+		return nil, errorUtil.Wrapf(providers.ErrInvalidOverrides, "overrides are invalid, %s", err.Error())
+	}
+	if bucketCfg, ok := fields["CreateBucketConfiguration"].(map[string]interface{}); ok && bucketCfg["LocationConstraint"] != nil && s3cbi.CreateBucketConfiguration != nil {
+		overridden.Region = normalizeBucketRegion(aws.StringValue(s3cbi.CreateBucketConfiguration.LocationConstraint))
+	}
+	return &overridden, nil
+}
+
+// disallowedOverrides Get the paths of the fields set in overrides that aren't allowed, a field is allowed if it or
+// one of the objects containing it is in the allowed paths
+func disallowedOverrides(prefix string, overrides map[string]interface{}, allowed []string) []string {
+	var disallowed []string
+	for field, value := range overrides {
+		path := prefix + field
+		if isAllowedPath(path, allowed) {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			disallowed = append(disallowed, disallowedOverrides(path+".", nested, allowed)...)
+			continue
+		}
+		disallowed = append(disallowed, path)
+	}
+	sort.Strings(disallowed)
+	return disallowed
+}
+
+func isAllowedPath(path string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == path {
+			return true
+		}
+	}
+	return false
+}
+
+// merge Merge a JSON merge patch into the strategy, an empty patch leaves it unchanged
+func (s *StrategyConfig) merge(patch json.RawMessage) error {
+	if len(patch) == 0 {
+		return nil
+	}
+	merged, err := jsonpatch.MergePatch(s.RawStrategy, patch)
+	if err != nil {
+		return err
+	}
+	s.RawStrategy = merged
+	return nil
+}
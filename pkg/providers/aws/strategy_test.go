@@ -0,0 +1,204 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+)
+
+func TestResolveTier(t *testing.T) {
+	rawStrategies := `{
+		"base": {"base": true, "region": "eu-west-1", "strategy": {"ACL": "private", "ObjectLockEnabledForBucket": true}, "regionOverrides": {"us-east-1": {"ACL": "public-read"}}},
+		"development": {"extends": "base", "strategy": {"ObjectLockEnabledForBucket": null}},
+		"production": {"extends": "development", "region": "us-east-1", "strategy": {"GrantRead": "test"}, "regionOverrides": {"us-east-1": {"GrantRead": "override"}}},
+		"standalone": {"region": "eu-west-1", "strategy": {}},
+		"minio": {"base": true, "endpoint": "http://minio:9000", "forcePathStyle": true, "disableSSL": true, "strategy": {}},
+		"on-prem": {"extends": "minio", "region": "us-east-1", "strategy": {}},
+		"cycle-a": {"extends": "cycle-b", "strategy": {}},
+		"cycle-b": {"extends": "cycle-a", "strategy": {}},
+		"orphan": {"extends": "missing", "strategy": {}}
+	}`
+	cases := []struct {
+		name             string
+		tier             string
+		expectError      bool
+		expectedRegion   string
+		expectedStrategy string
+		expectedEndpoint string
+	}{
+		{
+			name:             "test tier without base is unchanged",
+			tier:             "standalone",
+			expectedRegion:   "eu-west-1",
+			expectedStrategy: "{}",
+		},
+		{
+			name:             "test tier is merged onto base using json merge patch",
+			tier:             "development",
+			expectedRegion:   "eu-west-1",
+			expectedStrategy: "{\"ACL\":\"private\"}",
+		},
+		{
+			name:             "test region overrides of every tier in the chain are applied after strategies",
+			tier:             "production",
+			expectedRegion:   "us-east-1",
+			expectedStrategy: "{\"ACL\":\"public-read\",\"GrantRead\":\"override\"}",
+		},
+		{
+			name:             "test endpoint settings are inherited from extended tier",
+			tier:             "on-prem",
+			expectedRegion:   "us-east-1",
+			expectedStrategy: "{}",
+			expectedEndpoint: "http://minio:9000",
+		},
+		{
+			name:        "test error is returned for cycle in extended tiers",
+			tier:        "cycle-a",
+			expectError: true,
+		},
+		{
+			name:        "test error is returned when extended tier isn't defined",
+			tier:        "orphan",
+			expectError: true,
+		},
+		{
+			name:        "test error is returned when tier isn't defined",
+			tier:        "missing",
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stratCfg, err := ResolveTier([]byte(rawStrategies), tc.tier)
+			if err != nil {
+				if tc.expectError {
+					return
+				}
+				t.Fatal("failed to resolve tier", err)
+			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if stratCfg.Region != tc.expectedRegion {
+				t.Fatalf("unexpected region, expected %s but got %s", tc.expectedRegion, stratCfg.Region)
+			}
+			if string(stratCfg.RawStrategy) != tc.expectedStrategy {
+				t.Fatalf("unexpected strategy, expected %s but got %s", tc.expectedStrategy, string(stratCfg.RawStrategy))
+			}
+			if stratCfg.Endpoint != tc.expectedEndpoint {
+				t.Fatalf("unexpected endpoint, expected %s but got %s", tc.expectedEndpoint, stratCfg.Endpoint)
+			}
+			if tc.expectedEndpoint != "" && (!stratCfg.ForcePathStyle || !stratCfg.DisableSSL) {
+				t.Fatal("expected endpoint settings to be inherited with the endpoint")
+			}
+		})
+	}
+}
+
+func TestResolveStrategies(t *testing.T) {
+	tiers := map[string]*StrategyConfig{
+		"base": {
+			Base:        true,
+			RawStrategy: []byte("{\"ACL\":\"private\"}"),
+		},
+		"development": {
+			Extends:     "base",
+			RawStrategy: []byte("{}"),
+		},
+		"invalid": {
+			Extends:     "base",
+			RawStrategy: []byte("{\"ACL\":true}"),
+		},
+	}
+	resolved, invalid := ResolveStrategies(tiers, ValidateBlobStorageStrategy)
+	if _, ok := resolved["base"]; ok {
+		t.Fatal("unexpected base tier in resolved strategies")
+	}
+	if _, ok := resolved["development"]; !ok {
+		t.Fatal("expected development tier to be resolved")
+	}
+	if _, ok := invalid["invalid"]; !ok {
+		t.Fatal("expected invalid tier to be reported")
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	stratCfg := &StrategyConfig{
+		Region:      "eu-west-1",
+		RawStrategy: []byte("{\"ACL\":\"private\",\"CreateBucketConfiguration\":{\"LocationConstraint\":\"eu-west-1\"}}"),
+		Overridable: []string{"ObjectLockEnabledForBucket", "CreateBucketConfiguration.LocationConstraint", "VersioningConfiguration"},
+	}
+	cases := []struct {
+		name             string
+		overrides        string
+		expectedCause    error
+		expectedMessage  string
+		expectedStrategy string
+		expectedRegion   string
+	}{
+		{
+			name:             "test strategy is unchanged without overrides",
+			overrides:        "",
+			expectedStrategy: string(stratCfg.RawStrategy),
+			expectedRegion:   "eu-west-1",
+		},
+		{
+			name:             "test overridable fields are merged onto strategy",
+			overrides:        "{\"ObjectLockEnabledForBucket\":true,\"CreateBucketConfiguration\":{\"LocationConstraint\":\"us-west-1\"}}",
+			expectedStrategy: "{\"ACL\":\"private\",\"CreateBucketConfiguration\":{\"LocationConstraint\":\"us-west-1\"},\"ObjectLockEnabledForBucket\":true}",
+			expectedRegion:   "us-west-1",
+		},
+		{
+			name:             "test region is unchanged when location constraint isn't overridden",
+			overrides:        "{\"ObjectLockEnabledForBucket\":true}",
+			expectedStrategy: "{\"ACL\":\"private\",\"CreateBucketConfiguration\":{\"LocationConstraint\":\"eu-west-1\"},\"ObjectLockEnabledForBucket\":true}",
+			expectedRegion:   "eu-west-1",
+		},
+		{
+			name:            "test settings applied after bucket creation are rejected",
+			overrides:       "{\"VersioningConfiguration\":{\"Status\":\"Enabled\"}}",
+			expectedCause:   providers.ErrInvalidOverrides,
+			expectedMessage: "settings applied after a bucket is created such as versioning are not",
+		},
+		{
+			name:          "test fields that aren't overridable are rejected",
+			overrides:     "{\"ACL\":\"public-read\"}",
+			expectedCause: providers.ErrInvalidOverrides,
+		},
+		{
+			name:          "test nested fields that aren't overridable are rejected",
+			overrides:     "{\"CreateBucketConfiguration\":{\"Other\":\"test\"}}",
+			expectedCause: providers.ErrInvalidOverrides,
+		},
+		{
+			name:          "test overrides that aren't an object are rejected",
+			overrides:     "[]",
+			expectedCause: providers.ErrInvalidOverrides,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			overridden, err := ApplyOverrides(stratCfg, []byte(tc.overrides))
+			if tc.expectedCause != nil {
+				if errorUtil.Cause(err) != tc.expectedCause {
+					t.Fatalf("unexpected error cause, expected %v but got %v", tc.expectedCause, err)
+				}
+				if !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Fatalf("unexpected error message, expected %s but got %s", tc.expectedMessage, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to apply overrides", err)
+			}
+			if string(overridden.RawStrategy) != tc.expectedStrategy {
+				t.Fatalf("unexpected strategy, expected %s but got %s", tc.expectedStrategy, string(overridden.RawStrategy))
+			}
+			if overridden.Region != tc.expectedRegion {
+				t.Fatalf("unexpected region, expected %s but got %s", tc.expectedRegion, overridden.Region)
+			}
+		})
+	}
+}
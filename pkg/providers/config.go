@@ -3,7 +3,13 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sync"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
 	errorUtil "github.com/pkg/errors"
@@ -14,6 +20,8 @@ import (
 const (
 	DefaultConfigNamespace       = "kube-system"
 	DefaultProviderConfigMapName = "cloud-resource-config"
+
+	DefaultCloudResourceConfigName = "cloud-resource-config"
 )
 
 type DeploymentStrategyMapping struct {
@@ -21,12 +29,25 @@ type DeploymentStrategyMapping struct {
 }
 
 type ConfigManager struct {
-	client                     client.Client
+	Subscribers
+	client                     client.Reader
 	providerConfigMapName      string
 	providerConfigMapNamespace string
+
+	mu sync.Mutex
+	// the version of the objects the snapshot was read from
+	version  string
+	snapshot *DeploymentSnapshot
+}
+
+// DeploymentSnapshot The deployment types defined in the provider config at the time it was read
+type DeploymentSnapshot struct {
+	DeploymentTypes map[string]*DeploymentStrategyMapping
+	// deployment types defined in the legacy provider configmap that couldn't be unmarshalled
+	Invalid map[string]error
 }
 
-func NewConfigManager(cm string, namespace string, client client.Client) *ConfigManager {
+func NewConfigManager(cm string, namespace string, client client.Reader) *ConfigManager {
 	if cm == "" {
 		cm = DefaultProviderConfigMapName
 	}
@@ -40,16 +61,126 @@ func NewConfigManager(cm string, namespace string, client client.Client) *Config
 	}
 }
 
-// Get high-level information about the strategy used in a deployment type
+// Get high-level information about the strategy used in a deployment type, deployment types defined in the
+// CloudResourceConfig take precedence over those defined in the legacy provider configmap
 func (m *ConfigManager) GetStrategyMappingForDeploymentType(ctx context.Context, t string) (*DeploymentStrategyMapping, error) {
-	cm := &v1.ConfigMap{}
-	err := m.client.Get(ctx, types.NamespacedName{Name: m.providerConfigMapName, Namespace: m.providerConfigMapNamespace}, cm)
+	snapshot, err := m.Snapshot(ctx)
 	if err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to read provider config from configmap %s in namespace %s", m.providerConfigMapName, m.providerConfigMapNamespace)
+		return nil, err
 	}
-	dsm := &DeploymentStrategyMapping{}
-	if err = json.Unmarshal([]byte(cm.Data[t]), dsm); err != nil {
+	if dsm, ok := snapshot.DeploymentTypes[t]; ok {
+		// return a copy, the snapshot is shared between callers
+		dsmCopy := *dsm
+		return &dsmCopy, nil
+	}
+	if err, ok := snapshot.Invalid[t]; ok {
 		return nil, errorUtil.Wrapf(err, "failed to unmarshal config for deployment type %s", t)
 	}
-	return dsm, nil
+	return nil, errorUtil.New(fmt.Sprintf("deployment type %s is not defined in configmap %s in namespace %s", t, m.providerConfigMapName, m.providerConfigMapNamespace))
+}
+
+// Snapshot Get the deployment types currently defined, the config is only parsed again when the objects it's read from
+// have changed. Subscribers are notified of deployment types that changed since the previous snapshot
+func (m *ConfigManager) Snapshot(ctx context.Context) (*DeploymentSnapshot, error) {
+	cm, err := GetConfigMap(ctx, m.client, m.providerConfigMapName, m.providerConfigMapNamespace)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to read provider config")
+	}
+	crc, err := GetCloudResourceConfig(ctx, m.client)
+	if err != nil {
+		return nil, err
+	}
+	version := ConfigVersion(cm, crc)
+
+	m.mu.Lock()
+	if version != "" && version == m.version {
+		defer m.mu.Unlock()
+		return m.snapshot, nil
+	}
+	prev := m.snapshot
+	m.snapshot = buildDeploymentSnapshot(cm, crc)
+	m.version = version
+	snapshot := m.snapshot
+	m.mu.Unlock()
+
+	if prev != nil {
+		if changed := changedDeploymentTypes(prev, snapshot); len(changed) > 0 {
+			m.Notify(ConfigChange{DeploymentTypes: changed})
+		}
+	}
+	return snapshot, nil
+}
+
+// Watch Parse the config again whenever the objects it's read from change, so subscribers are notified without
+// waiting for the next read
+func (m *ConfigManager) Watch(w *ConfigWatcher) {
+	w.AddHandler(func(obj runtime.Object) {
+		if !IsConfigSource(obj, m.providerConfigMapName, m.providerConfigMapNamespace) {
+			return
+		}
+		if _, err := m.Snapshot(context.TODO()); err != nil {
+			log.Error(err, "failed to read provider config after it changed")
+		}
+	})
+}
+
+func buildDeploymentSnapshot(cm *v1.ConfigMap, crc *v1alpha1.CloudResourceConfig) *DeploymentSnapshot {
+	snapshot := &DeploymentSnapshot{
+		DeploymentTypes: map[string]*DeploymentStrategyMapping{},
+		Invalid:         map[string]error{},
+	}
+	if cm != nil {
+		for t, rawDsm := range cm.Data {
+			dsm := &DeploymentStrategyMapping{}
+			if err := json.Unmarshal([]byte(rawDsm), dsm); err != nil {
+				snapshot.Invalid[t] = err
+				continue
+			}
+			snapshot.DeploymentTypes[t] = dsm
+		}
+	}
+	if crc != nil {
+		for t, ds := range crc.Spec.DeploymentTypes {
+			snapshot.DeploymentTypes[t] = &DeploymentStrategyMapping{
+				BlobStorage: ds.BlobStorage,
+			}
+			delete(snapshot.Invalid, t)
+		}
+	}
+	return snapshot
+}
+
+// changedDeploymentTypes Get the deployment types that were added, removed or changed between two snapshots
+func changedDeploymentTypes(prev, next *DeploymentSnapshot) []string {
+	changed := map[string]bool{}
+	for t, dsm := range next.DeploymentTypes {
+		if prevDsm, ok := prev.DeploymentTypes[t]; !ok || *prevDsm != *dsm {
+			changed[t] = true
+		}
+	}
+	for t := range prev.DeploymentTypes {
+		if _, ok := next.DeploymentTypes[t]; !ok {
+			changed[t] = true
+		}
+	}
+	return SortedKeys(changed)
+}
+
+// GetCloudResourceConfig Get the cluster-wide CloudResourceConfig, nil is returned if it or its CRD don't exist so
+// callers can fall back to the legacy configmaps
+func GetCloudResourceConfig(ctx context.Context, c client.Reader) (*v1alpha1.CloudResourceConfig, error) {
+	crc := &v1alpha1.CloudResourceConfig{}
+	err := c.Get(ctx, types.NamespacedName{Name: DefaultCloudResourceConfigName}, crc)
+	if err != nil {
+		if isAbsent(err) {
+			return nil, nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get cloud resource config %s", DefaultCloudResourceConfigName)
+	}
+	return crc, nil
+}
+
+// isAbsent Check whether err was caused by an object, its kind or its CRD not existing
+func isAbsent(err error) bool {
+	return errors.IsNotFound(err) || meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err)
 }
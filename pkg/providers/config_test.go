@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
@@ -78,19 +80,28 @@ func TestConfigManager_GetStrategyMappingForDeploymentType(t *testing.T) {
 			ManagedDeploymentType: string(testDtcJSON),
 		},
 	})
+	crdScheme := runtime.NewScheme()
+	if err = v1.AddToScheme(crdScheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err = v1alpha1.SchemeBuilder.AddToScheme(crdScheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
 	cases := []struct {
 		name           string
 		cmName         string
 		cmNamespace    string
+		deploymentType string
 		client         client.Client
 		expectError    bool
 		validateConfig func(dtc *DeploymentStrategyMapping) error
 	}{
 		{
-			name:        "test config is unmarshalled successfully when configmap is structured correctly",
-			cmName:      "test",
-			cmNamespace: "test",
-			client:      fakeClient,
+			name:           "test config is unmarshalled successfully when configmap is structured correctly",
+			cmName:         "test",
+			cmNamespace:    "test",
+			deploymentType: ManagedDeploymentType,
+			client:         fakeClient,
 			validateConfig: func(dtc *DeploymentStrategyMapping) error {
 				if dtc.BlobStorage != AWSDeploymentStrategy {
 					return errors.New("strategy mapping has incorrect structure")
@@ -99,23 +110,90 @@ func TestConfigManager_GetStrategyMappingForDeploymentType(t *testing.T) {
 			},
 		},
 		{
-			name:        "test error is returned when config map doesn't exist",
-			cmName:      "err",
-			cmNamespace: "err",
-			client:      fakeClient,
-			expectError: true,
+			name:           "test error is returned when config map doesn't exist",
+			cmName:         "err",
+			cmNamespace:    "err",
+			deploymentType: ManagedDeploymentType,
+			client:         fakeClient,
+			expectError:    true,
+		},
+		{
+			name:           "test deployment type defined in cloud resource config takes precedence",
+			cmName:         "test",
+			cmNamespace:    "test",
+			deploymentType: ManagedDeploymentType,
+			client: fake.NewFakeClientWithScheme(crdScheme, &v1.ConfigMap{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+				Data: map[string]string{
+					ManagedDeploymentType: "{\"blobstorage\":\"openshift\"}",
+				},
+			}, &v1alpha1.CloudResourceConfig{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name: DefaultCloudResourceConfigName,
+				},
+				Spec: v1alpha1.CloudResourceConfigSpec{
+					DeploymentTypes: map[string]v1alpha1.DeploymentStrategies{
+						ManagedDeploymentType: {BlobStorage: AWSDeploymentStrategy},
+					},
+				},
+			}),
+			validateConfig: func(dtc *DeploymentStrategyMapping) error {
+				if dtc.BlobStorage != AWSDeploymentStrategy {
+					return errors.New("strategy mapping was not read from cloud resource config")
+				}
+				return nil
+			},
+		},
+		{
+			name:           "test legacy configmap is used when deployment type isn't in cloud resource config",
+			cmName:         "test",
+			cmNamespace:    "test",
+			deploymentType: ManagedDeploymentType,
+			client: fake.NewFakeClientWithScheme(crdScheme, &v1.ConfigMap{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+				Data: map[string]string{
+					ManagedDeploymentType: string(testDtcJSON),
+				},
+			}, &v1alpha1.CloudResourceConfig{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name: DefaultCloudResourceConfigName,
+				},
+			}),
+			validateConfig: func(dtc *DeploymentStrategyMapping) error {
+				if dtc.BlobStorage != AWSDeploymentStrategy {
+					return errors.New("strategy mapping was not read from configmap")
+				}
+				return nil
+			},
+		},
+		{
+			name:           "test error is returned when deployment type isn't defined",
+			cmName:         "test",
+			cmNamespace:    "test",
+			deploymentType: "missing",
+			client:         fakeClient,
+			expectError:    true,
 		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			cm := NewConfigManager(tc.cmName, tc.cmNamespace, tc.client)
-			dtc, err := cm.GetStrategyMappingForDeploymentType(context.TODO(), ManagedDeploymentType)
+			dtc, err := cm.GetStrategyMappingForDeploymentType(context.TODO(), tc.deploymentType)
 			if err != nil {
 				if tc.expectError {
 					return
 				}
 				t.Fatal("failed to read deployment type config", err)
 			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
 			err = tc.validateConfig(dtc)
 			if err != nil {
 				t.Fatal("failed to validate deployment type config", err)
@@ -123,3 +201,89 @@ func TestConfigManager_GetStrategyMappingForDeploymentType(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigManager_Snapshot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	buildConfigMap := func(resourceVersion string, data map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:            "test",
+				Namespace:       "test",
+				ResourceVersion: resourceVersion,
+			},
+			Data: data,
+		}
+	}
+	cases := []struct {
+		name            string
+		initial         *v1.ConfigMap
+		updated         *v1.ConfigMap
+		expectedChanges []string
+		expectedMapping string
+	}{
+		{
+			name:            "test subscribers are notified of changed deployment types",
+			initial:         buildConfigMap("1", map[string]string{ManagedDeploymentType: "{\"blobstorage\":\"aws\"}", "other": "{\"blobstorage\":\"aws\"}"}),
+			updated:         buildConfigMap("2", map[string]string{ManagedDeploymentType: "{\"blobstorage\":\"openshift\"}", "other": "{\"blobstorage\":\"aws\"}"}),
+			expectedChanges: []string{ManagedDeploymentType},
+			expectedMapping: "openshift",
+		},
+		{
+			name:            "test subscribers are notified of added and removed deployment types",
+			initial:         buildConfigMap("1", map[string]string{"other": "{\"blobstorage\":\"aws\"}"}),
+			updated:         buildConfigMap("2", map[string]string{ManagedDeploymentType: "{\"blobstorage\":\"aws\"}"}),
+			expectedChanges: []string{ManagedDeploymentType, "other"},
+			expectedMapping: AWSDeploymentStrategy,
+		},
+		{
+			name:            "test subscribers are not notified when config is unchanged",
+			initial:         buildConfigMap("1", map[string]string{ManagedDeploymentType: "{\"blobstorage\":\"aws\"}"}),
+			updated:         buildConfigMap("2", map[string]string{ManagedDeploymentType: "{\"blobstorage\": \"aws\"}"}),
+			expectedMapping: AWSDeploymentStrategy,
+		},
+		{
+			name:            "test config is not parsed again when resource version is unchanged",
+			initial:         buildConfigMap("1", map[string]string{ManagedDeploymentType: "{\"blobstorage\":\"aws\"}"}),
+			updated:         buildConfigMap("1", map[string]string{ManagedDeploymentType: "{\"blobstorage\":\"openshift\"}"}),
+			expectedMapping: AWSDeploymentStrategy,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := fake.NewFakeClientWithScheme(scheme, tc.initial)
+			cm := NewConfigManager("test", "test", fakeClient)
+			var changes []ConfigChange
+			cm.Subscribe(func(change ConfigChange) {
+				changes = append(changes, change)
+			})
+			if _, err := cm.Snapshot(context.TODO()); err != nil {
+				t.Fatal("failed to read initial snapshot", err)
+			}
+			if err := fakeClient.Update(context.TODO(), tc.updated); err != nil {
+				t.Fatal("failed to update config map", err)
+			}
+			dsm, err := cm.GetStrategyMappingForDeploymentType(context.TODO(), ManagedDeploymentType)
+			if err != nil {
+				t.Fatal("failed to read deployment type config", err)
+			}
+			if dsm.BlobStorage != tc.expectedMapping {
+				t.Fatalf("unexpected strategy mapping, expected %s but got %s", tc.expectedMapping, dsm.BlobStorage)
+			}
+			if tc.expectedChanges == nil {
+				if len(changes) != 0 {
+					t.Fatalf("unexpected notification, got %v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 {
+				t.Fatalf("unexpected number of notifications, expected 1 but got %d", len(changes))
+			}
+			if !reflect.DeepEqual(changes[0].DeploymentTypes, tc.expectedChanges) {
+				t.Fatalf("unexpected changed deployment types, expected %v but got %v", tc.expectedChanges, changes[0].DeploymentTypes)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var log = logf.Log.WithName("providers")
+
+// ConfigChange The deployment types and tiers whose definition changed between two reads of the provider config
+type ConfigChange struct {
+	DeploymentTypes []string
+	Tiers           map[ResourceType][]string
+}
+
+// ConfigSubscriber Called by a config manager when the definition of one or more deployment types or tiers changes
+type ConfigSubscriber func(change ConfigChange)
+
+// Subscribers A list of subscribers to be notified of config changes, embedded by config managers
+type Subscribers struct {
+	mu   sync.Mutex
+	subs []ConfigSubscriber
+}
+
+// Subscribe Add a subscriber to be notified when the config changes
+func (s *Subscribers) Subscribe(fn ConfigSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, fn)
+}
+
+// Notify Call every subscriber with the change
+func (s *Subscribers) Notify(change ConfigChange) {
+	s.mu.Lock()
+	subs := append([]ConfigSubscriber{}, s.subs...)
+	s.mu.Unlock()
+	for _, fn := range subs {
+		fn(change)
+	}
+}
+
+// ConfigWatcher Calls its handlers whenever a configmap in the config namespace or the CloudResourceConfig changes,
+// config managers reading from the same cache use this to parse the config again and notify their subscribers
+type ConfigWatcher struct {
+	mu       sync.Mutex
+	handlers []func(obj runtime.Object)
+}
+
+// AddHandler Add a handler to be called with every changed config object
+func (w *ConfigWatcher) AddHandler(fn func(obj runtime.Object)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// Watch Register the watcher with the configmap and CloudResourceConfig informers of a cache, the CloudResourceConfig
+// isn't watched if its CRD isn't installed
+func (w *ConfigWatcher) Watch(informers cache.Informers) error {
+	h := toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    w.handle,
+		UpdateFunc: func(_, obj interface{}) { w.handle(obj) },
+		DeleteFunc: w.handle,
+	}
+	for _, obj := range []runtime.Object{&v1.ConfigMap{}, &v1alpha1.CloudResourceConfig{}} {
+		i, err := informers.GetInformer(obj)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				log.Info("Config kind is not installed, it will not be watched", "Type", fmt.Sprintf("%T", obj))
+				continue
+			}
+			return errorUtil.Wrap(err, "failed to get informer for config")
+		}
+		i.AddEventHandler(h)
+	}
+	return nil
+}
+
+func (w *ConfigWatcher) handle(obj interface{}) {
+	if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	handlers := append([]func(obj runtime.Object){}, w.handlers...)
+	w.mu.Unlock()
+	for _, fn := range handlers {
+		fn(o)
+	}
+}
+
+// IsConfigSource Check whether obj is the configmap cmName in namespace cmNamespace or the CloudResourceConfig
+func IsConfigSource(obj runtime.Object, cmName, cmNamespace string) bool {
+	switch o := obj.(type) {
+	case *v1.ConfigMap:
+		return o.Name == cmName && o.Namespace == cmNamespace
+	case *v1alpha1.CloudResourceConfig:
+		return o.Name == DefaultCloudResourceConfigName
+	}
+	return false
+}
+
+// ConfigVersion Build a version for config read from cm and crc, either of which is nil if it doesn't exist. An empty
+// version is returned if an existing object has no resource version, config read from it is never cached
+func ConfigVersion(cm *v1.ConfigMap, crc *v1alpha1.CloudResourceConfig) string {
+	cmVersion, crcVersion := "-", "-"
+	if cm != nil {
+		if cm.ResourceVersion == "" {
+			return ""
+		}
+		cmVersion = cm.ResourceVersion
+	}
+	if crc != nil {
+		if crc.ResourceVersion == "" {
+			return ""
+		}
+		crcVersion = crc.ResourceVersion
+	}
+	return cmVersion + "/" + crcVersion
+}
+
+// GetConfigMap Get a configmap config is read from, nil is returned if it doesn't exist
+func GetConfigMap(ctx context.Context, c client.Reader, name, ns string) (*v1.ConfigMap, error) {
+	cm := &v1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, cm); err != nil {
+		if isAbsent(err) {
+			return nil, nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get configmap %s in namespace %s", name, ns)
+	}
+	return cm, nil
+}
+
+// SortedKeys Get the keys of a set in order
+func SortedKeys(set map[string]bool) []string {
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configCache A cache for reading config, reads block until the cache has been started and synced so config isn't
+// reported as missing while the operator starts
+type configCache struct {
+	cache.Cache
+	synced chan struct{}
+}
+
+var (
+	configCachesMu sync.Mutex
+	// config caches by the manager they're started with, shared between its controllers and webhooks
+	configCaches = map[manager.Manager]cache.Cache{}
+)
+
+// GetConfigCache Get the informer-backed cache limited to the config namespace, so config can be read whichever
+// namespace the operator watches. It's created and added to the manager on first use, later calls with the same
+// manager share it so config objects are only watched once
+func GetConfigCache(mgr manager.Manager) (cache.Cache, error) {
+	configCachesMu.Lock()
+	defer configCachesMu.Unlock()
+	if c, ok := configCaches[mgr]; ok {
+		return c, nil
+	}
+	c, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: DefaultConfigNamespace,
+	})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create config cache")
+	}
+	cfgCache := &configCache{
+		Cache:  c,
+		synced: make(chan struct{}),
+	}
+	if err = mgr.Add(cfgCache); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to add config cache to manager")
+	}
+	configCaches[mgr] = cfgCache
+	return cfgCache, nil
+}
+
+func (c *configCache) Start(stop <-chan struct{}) error {
+	go func() {
+		if c.Cache.WaitForCacheSync(stop) {
+			close(c.synced)
+		}
+	}()
+	return c.Cache.Start(stop)
+}
+
+func (c *configCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	select {
+	case <-c.synced:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return c.Cache.Get(ctx, key, obj)
+}
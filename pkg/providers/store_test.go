@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// fakeManager Manager recording the runnables added to it, only the methods used to build a cache are implemented
+type fakeManager struct {
+	manager.Manager
+	runnables []manager.Runnable
+}
+
+func (m *fakeManager) Add(r manager.Runnable) error {
+	m.runnables = append(m.runnables, r)
+	return nil
+}
+
+func (m *fakeManager) GetConfig() *rest.Config {
+	return &rest.Config{Host: "http://localhost"}
+}
+
+func (m *fakeManager) GetScheme() *runtime.Scheme {
+	return runtime.NewScheme()
+}
+
+func (m *fakeManager) GetRESTMapper() meta.RESTMapper {
+	return meta.NewDefaultRESTMapper(nil)
+}
+
+func TestGetConfigCache(t *testing.T) {
+	mgr := &fakeManager{}
+	first, err := GetConfigCache(mgr)
+	if err != nil {
+		t.Fatal("failed to get config cache", err)
+	}
+	second, err := GetConfigCache(mgr)
+	if err != nil {
+		t.Fatal("failed to get config cache", err)
+	}
+	if first != second {
+		t.Fatal("expected the config cache to be shared between callers using the same manager")
+	}
+	if len(mgr.runnables) != 1 {
+		t.Fatalf("unexpected runnables, expected config cache to be added to the manager once but got %d", len(mgr.runnables))
+	}
+
+	other, err := GetConfigCache(&fakeManager{})
+	if err != nil {
+		t.Fatal("failed to get config cache", err)
+	}
+	if other == first {
+		t.Fatal("expected a separate config cache for another manager")
+	}
+}
@@ -2,6 +2,8 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,10 +22,32 @@ const (
 	SMTPCredentialResourceType ResourceType = "smtpcredential"
 )
 
+var (
+	// ErrTierNotFound the requested tier is not defined for the provider
+	ErrTierNotFound = errors.New("tier not found")
+	// ErrInvalidStrategy the strategy defined for the requested tier can't be used by the provider
+	ErrInvalidStrategy = errors.New("invalid strategy")
+	// ErrInvalidOverrides the overrides of a resource can't be applied to the strategy of its tier
+	ErrInvalidOverrides = errors.New("invalid overrides")
+)
+
 type BlobStorageInstance struct {
 	DeploymentDetails BlobStorageDeploymentDetails
 }
 
+// Drift A setting of a cloud resource that differs from its strategy
+type Drift struct {
+	Field   string
+	Desired string
+	Actual  string
+	// Fixed is set if the provider has corrected the setting
+	Fixed bool
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s is %s but should be %s", d.Field, d.Actual, d.Desired)
+}
+
 type BlobStorageDeploymentDetails interface {
 	Data() map[string][]byte
 }
@@ -31,6 +55,17 @@ type BlobStorageDeploymentDetails interface {
 type BlobStorageProvider interface {
 	GetName() string
 	SupportsStrategy(s string) bool
+	TierExists(ctx context.Context, tier string) (bool, error)
 	CreateStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) (*BlobStorageInstance, error)
 	DeleteStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) error
+	// PlanStorage Describe the changes CreateStorage, or DeleteStorage if the resource is being deleted, would make
+	// without calling mutating cloud provider apis or creating objects
+	PlanStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) ([]string, error)
+	// DescribeStorage Describe the actual state of the cloud resource and compare it with its strategy, only read-only
+	// cloud provider apis are called. No drift is returned if the cloud resource doesn't exist
+	DescribeStorage(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage) ([]Drift, error)
+	// CorrectDrift Correct the settings in drift, as returned by DescribeStorage, that can be changed safely without
+	// describing the cloud resource again. The drift is returned with the corrected settings marked as fixed, the
+	// settings managed by the provider are recorded in the status of the instance
+	CorrectDrift(ctx context.Context, client client.Client, bs *v1alpha1.BlobStorage, drift []Drift) ([]Drift, error)
 }
@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"strconv"
+
+	controllerruntime "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// AnnotationPaused Stops the operator from reconciling the resource, no changes are made to the cloud resource
+	// or to the secret while set to true
+	AnnotationPaused = "cloud-resources.integreatly.org/paused"
+	// AnnotationDeletionProtection Stops the operator from removing the cloud resource when the resource is deleted
+	// while set to true
+	AnnotationDeletionProtection = "cloud-resources.integreatly.org/deletion-protection"
+	// AnnotationDryRun Stops the operator from changing the cloud resource while set to true, the changes it would
+	// make are written to the status of the resource instead
+	AnnotationDryRun = "cloud-resources.integreatly.org/dry-run"
+)
+
+// dryRun is set when the whole operator runs in dry-run mode
+var dryRun bool
+
+// SetDryRun Enable or disable dry-run mode for every resource
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+func IsPaused(om *controllerruntime.ObjectMeta) bool {
+	return isAnnotationTrue(om, AnnotationPaused)
+}
+
+func IsDeletionProtected(om *controllerruntime.ObjectMeta) bool {
+	return isAnnotationTrue(om, AnnotationDeletionProtection)
+}
+
+// IsDryRun Check whether changes to the cloud resource should only be planned, either because the operator runs in
+// dry-run mode or the resource has the dry-run annotation
+func IsDryRun(om *controllerruntime.ObjectMeta) bool {
+	return dryRun || isAnnotationTrue(om, AnnotationDryRun)
+}
+
+func isAnnotationTrue(om *controllerruntime.ObjectMeta, annotation string) bool {
+	v, err := strconv.ParseBool(om.GetAnnotations()[annotation])
+	return err == nil && v
+}
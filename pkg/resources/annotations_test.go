@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"testing"
+
+	controllerruntime "sigs.k8s.io/controller-runtime"
+)
+
+func TestIsPaused(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedResult bool
+	}{
+		{
+			name:           "test returns true when annotation is true",
+			annotations:    map[string]string{AnnotationPaused: "true"},
+			expectedResult: true,
+		},
+		{
+			name:           "test returns false when annotation is false",
+			annotations:    map[string]string{AnnotationPaused: "false"},
+			expectedResult: false,
+		},
+		{
+			name:           "test returns false when annotation is not a boolean",
+			annotations:    map[string]string{AnnotationPaused: "yes please"},
+			expectedResult: false,
+		},
+		{
+			name:           "test returns false when annotation isn't present",
+			annotations:    nil,
+			expectedResult: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			om := &controllerruntime.ObjectMeta{
+				Annotations: tc.annotations,
+			}
+			if IsPaused(om) != tc.expectedResult {
+				t.Fatalf("unexpected result, expected %t but got %t", tc.expectedResult, IsPaused(om))
+			}
+		})
+	}
+}
+
+func TestIsDeletionProtected(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedResult bool
+	}{
+		{
+			name:           "test returns true when annotation is true",
+			annotations:    map[string]string{AnnotationDeletionProtection: "true"},
+			expectedResult: true,
+		},
+		{
+			name:           "test returns false when annotation isn't present",
+			annotations:    map[string]string{AnnotationPaused: "true"},
+			expectedResult: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			om := &controllerruntime.ObjectMeta{
+				Annotations: tc.annotations,
+			}
+			if IsDeletionProtected(om) != tc.expectedResult {
+				t.Fatalf("unexpected result, expected %t but got %t", tc.expectedResult, IsDeletionProtected(om))
+			}
+		})
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		operatorDryRun bool
+		expectedResult bool
+	}{
+		{
+			name:           "test returns true when annotation is true",
+			annotations:    map[string]string{AnnotationDryRun: "true"},
+			expectedResult: true,
+		},
+		{
+			name:           "test returns true when operator runs in dry-run mode",
+			annotations:    nil,
+			operatorDryRun: true,
+			expectedResult: true,
+		},
+		{
+			name:           "test annotation can't disable operator dry-run mode",
+			annotations:    map[string]string{AnnotationDryRun: "false"},
+			operatorDryRun: true,
+			expectedResult: true,
+		},
+		{
+			name:           "test returns false when annotation isn't present",
+			annotations:    nil,
+			expectedResult: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetDryRun(tc.operatorDryRun)
+			defer SetDryRun(false)
+			om := &controllerruntime.ObjectMeta{
+				Annotations: tc.annotations,
+			}
+			if IsDryRun(om) != tc.expectedResult {
+				t.Fatalf("unexpected result, expected %t but got %t", tc.expectedResult, IsDryRun(om))
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package resources
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetCondition Add or update a condition of the given type, the transition time is only changed when the status changes
+func SetCondition(conditions []v1alpha1.Condition, t v1alpha1.ConditionType, status v1.ConditionStatus, reason, message string) []v1alpha1.Condition {
+	for i, c := range conditions {
+		if c.Type != t {
+			continue
+		}
+		if c.Status != status {
+			conditions[i].LastTransitionTime = metav1.Now()
+		}
+		conditions[i].Status = status
+		conditions[i].Reason = reason
+		conditions[i].Message = message
+		return conditions
+	}
+	return append(conditions, v1alpha1.Condition{
+		Type:               t,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	})
+}
+
+// RemoveCondition Remove the condition of the given type if it exists
+func RemoveCondition(conditions []v1alpha1.Condition, t v1alpha1.ConditionType) []v1alpha1.Condition {
+	for i, c := range conditions {
+		if c.Type == t {
+			return append(conditions[:i], conditions[i+1:]...)
+		}
+	}
+	return conditions
+}
+
+// FindCondition Return the condition of the given type, or nil if it doesn't exist
+func FindCondition(conditions []v1alpha1.Condition, t v1alpha1.ConditionType) *v1alpha1.Condition {
+	for i, c := range conditions {
+		if c.Type == t {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	transitioned := metav1.Unix(0, 0)
+	cases := []struct {
+		name                   string
+		existingConditions     []v1alpha1.Condition
+		status                 v1.ConditionStatus
+		expectedLength         int
+		expectTransitionUpdate bool
+	}{
+		{
+			name:                   "test condition is appended when it doesn't exist",
+			existingConditions:     []v1alpha1.Condition{},
+			status:                 v1.ConditionTrue,
+			expectedLength:         1,
+			expectTransitionUpdate: true,
+		},
+		{
+			name: "test transition time is kept when status is unchanged",
+			existingConditions: []v1alpha1.Condition{
+				{Type: v1alpha1.ConditionPaused, Status: v1.ConditionTrue, LastTransitionTime: transitioned},
+			},
+			status:                 v1.ConditionTrue,
+			expectedLength:         1,
+			expectTransitionUpdate: false,
+		},
+		{
+			name: "test transition time is updated when status changes",
+			existingConditions: []v1alpha1.Condition{
+				{Type: v1alpha1.ConditionPaused, Status: v1.ConditionFalse, LastTransitionTime: transitioned},
+			},
+			status:                 v1.ConditionTrue,
+			expectedLength:         1,
+			expectTransitionUpdate: true,
+		},
+		{
+			name: "test other conditions are kept",
+			existingConditions: []v1alpha1.Condition{
+				{Type: v1alpha1.ConditionDeletionBlocked, Status: v1.ConditionTrue, LastTransitionTime: transitioned},
+			},
+			status:                 v1.ConditionTrue,
+			expectedLength:         2,
+			expectTransitionUpdate: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conditions := SetCondition(tc.existingConditions, v1alpha1.ConditionPaused, tc.status, "test", "test")
+			if len(conditions) != tc.expectedLength {
+				t.Fatalf("unexpected conditions length, expected %d but got %d", tc.expectedLength, len(conditions))
+			}
+			c := FindCondition(conditions, v1alpha1.ConditionPaused)
+			if c == nil {
+				t.Fatal("expected condition to be set")
+			}
+			if c.Status != tc.status {
+				t.Fatalf("unexpected status, expected %s but got %s", tc.status, c.Status)
+			}
+			if c.LastTransitionTime.Equal(&transitioned) == tc.expectTransitionUpdate {
+				t.Fatalf("unexpected transition time %s", c.LastTransitionTime)
+			}
+		})
+	}
+}
+
+func TestRemoveCondition(t *testing.T) {
+	cases := []struct {
+		name               string
+		existingConditions []v1alpha1.Condition
+		expectedLength     int
+	}{
+		{
+			name:               "test removing non-existent condition does nothing",
+			existingConditions: []v1alpha1.Condition{},
+			expectedLength:     0,
+		},
+		{
+			name: "test removing existing condition keeps other conditions",
+			existingConditions: []v1alpha1.Condition{
+				{Type: v1alpha1.ConditionPaused},
+				{Type: v1alpha1.ConditionDeletionBlocked},
+			},
+			expectedLength: 1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conditions := RemoveCondition(tc.existingConditions, v1alpha1.ConditionPaused)
+			if len(conditions) != tc.expectedLength {
+				t.Fatalf("unexpected conditions length, expected %d but got %d", tc.expectedLength, len(conditions))
+			}
+			if FindCondition(conditions, v1alpha1.ConditionPaused) != nil {
+				t.Fatal("expected condition to be removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package resources
+
+import (
+	"context"
+	"os"
+
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EnvDefaultDeploymentType operator-wide deployment type used when a resource doesn't specify one
+	EnvDefaultDeploymentType = "DEFAULT_DEPLOYMENT_TYPE"
+	// EnvDefaultTier operator-wide tier used when a resource doesn't specify one
+	EnvDefaultTier = "DEFAULT_TIER"
+
+	// KeyDefaultDeploymentType can be set as a label or annotation on a namespace to override the operator-wide
+	// deployment type default for resources in that namespace, annotations take precedence over labels
+	KeyDefaultDeploymentType = "cloud-resources.integreatly.org/default-type"
+	// KeyDefaultTier can be set as a label or annotation on a namespace to override the operator-wide tier default
+	// for resources in that namespace, annotations take precedence over labels
+	KeyDefaultTier = "cloud-resources.integreatly.org/default-tier"
+)
+
+// Defaults The values used for fields a resource doesn't specify
+type Defaults struct {
+	DeploymentType string
+	Tier           string
+}
+
+// GetDefaults Resolve the defaults for resources in namespace ns, values set on the namespace override the
+// operator-wide defaults
+func GetDefaults(ctx context.Context, c client.Client, ns string) (*Defaults, error) {
+	d := &Defaults{
+		DeploymentType: os.Getenv(EnvDefaultDeploymentType),
+		Tier:           os.Getenv(EnvDefaultTier),
+	}
+	namespace := &v1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ns}, namespace); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get namespace %s", ns)
+	}
+	if v := namespaceValue(namespace, KeyDefaultDeploymentType); v != "" {
+		d.DeploymentType = v
+	}
+	if v := namespaceValue(namespace, KeyDefaultTier); v != "" {
+		d.Tier = v
+	}
+	return d, nil
+}
+
+func namespaceValue(ns *v1.Namespace, key string) string {
+	if v := ns.GetAnnotations()[key]; v != "" {
+		return v
+	}
+	return ns.GetLabels()[key]
+}
@@ -0,0 +1,102 @@
+package resources
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetDefaults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	fakeClient := fake.NewFakeClientWithScheme(scheme, &v1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "plain",
+		},
+	}, &v1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "labelled",
+			Labels: map[string]string{
+				KeyDefaultTier: "production",
+			},
+		},
+	}, &v1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "annotated",
+			Labels: map[string]string{
+				KeyDefaultTier: "production",
+			},
+			Annotations: map[string]string{
+				KeyDefaultDeploymentType: "workshop",
+				KeyDefaultTier:           "staging",
+			},
+		},
+	})
+	if err = os.Setenv(EnvDefaultDeploymentType, "managed"); err != nil {
+		t.Fatal("failed to set env", err)
+	}
+	defer os.Unsetenv(EnvDefaultDeploymentType)
+	if err = os.Setenv(EnvDefaultTier, "development"); err != nil {
+		t.Fatal("failed to set env", err)
+	}
+	defer os.Unsetenv(EnvDefaultTier)
+	cases := []struct {
+		name                   string
+		namespace              string
+		expectedDeploymentType string
+		expectedTier           string
+		expectError            bool
+	}{
+		{
+			name:                   "test operator defaults are used when namespace has no overrides",
+			namespace:              "plain",
+			expectedDeploymentType: "managed",
+			expectedTier:           "development",
+		},
+		{
+			name:                   "test namespace label overrides operator default",
+			namespace:              "labelled",
+			expectedDeploymentType: "managed",
+			expectedTier:           "production",
+		},
+		{
+			name:                   "test namespace annotation takes precedence over label",
+			namespace:              "annotated",
+			expectedDeploymentType: "workshop",
+			expectedTier:           "staging",
+		},
+		{
+			name:        "test error is returned when namespace doesn't exist",
+			namespace:   "missing",
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := GetDefaults(context.TODO(), fakeClient, tc.namespace)
+			if err != nil {
+				if tc.expectError {
+					return
+				}
+				t.Fatal("unexpected error", err)
+			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if d.DeploymentType != tc.expectedDeploymentType {
+				t.Fatalf("unexpected deployment type, expected %s but got %s", tc.expectedDeploymentType, d.DeploymentType)
+			}
+			if d.Tier != tc.expectedTier {
+				t.Fatalf("unexpected tier, expected %s but got %s", tc.expectedTier, d.Tier)
+			}
+		})
+	}
+}
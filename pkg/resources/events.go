@@ -0,0 +1,16 @@
+package resources
+
+// Reasons of the events recorded on resources managed by the operator, shared between resource kinds so events can be
+// filtered by reason whichever kind they were recorded on
+const (
+	EventReasonCredentialsRequestCreated = "CredentialsRequestCreated"
+	EventReasonCredentialsReady          = "CredentialsReady"
+	EventReasonResourceCreated           = "CloudResourceCreated"
+	EventReasonResourceAdopted           = "CloudResourceAdopted"
+	EventReasonSecretWritten             = "SecretWritten"
+	EventReasonSecretRemoved             = "SecretRemoved"
+	EventReasonDeletionStarted           = "DeletionStarted"
+	EventReasonDeletionBlocked           = "DeletionBlocked"
+	EventReasonDeletionCompleted         = "DeletionCompleted"
+	EventReasonReconcileFailed           = "ReconcileFailed"
+)
@@ -10,7 +10,7 @@ func HasFinalizer(om *controllerruntime.ObjectMeta, finalizer string) bool {
 
 func AddFinalizer(om *controllerruntime.ObjectMeta, finalizer string) {
 	if !contains(om.GetFinalizers(), finalizer) {
-		om.SetFinalizers([]string{finalizer})
+		om.SetFinalizers(append(om.GetFinalizers(), finalizer))
 	}
 }
 
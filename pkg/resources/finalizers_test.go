@@ -25,6 +25,12 @@ func TestAddFinalizer(t *testing.T) {
 			finalizer:          "test",
 			expectedLength:     1,
 		},
+		{
+			name:               "test finalizer is appended without removing other finalizers",
+			existingFinalizers: []string{"test2"},
+			finalizer:          "test",
+			expectedLength:     2,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -0,0 +1,47 @@
+package resources
+
+import (
+	"context"
+
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelSecretsFromNamespace must be set on a namespace, with the name of the source namespace as the value,
+	// before secrets for resources in the source namespace can be written to it
+	LabelSecretsFromNamespace = "cloud-resources.integreatly.org/secrets-from"
+
+	// labels set on secrets written outside the namespace of the resource they were created for, owner references
+	// can't span namespaces so these are used to track them instead
+	LabelOwnerName      = "cloud-resources.integreatly.org/owner-name"
+	LabelOwnerNamespace = "cloud-resources.integreatly.org/owner-namespace"
+)
+
+// IsSecretNamespaceAllowed Check whether secrets for resources in namespace source can be written to namespace target
+func IsSecretNamespaceAllowed(ctx context.Context, c client.Client, source, target string) (bool, error) {
+	if target == "" || target == source {
+		return true, nil
+	}
+	ns := &v1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: target}, ns); err != nil {
+		return false, errorUtil.Wrapf(err, "failed to get target namespace %s", target)
+	}
+	return ns.GetLabels()[LabelSecretsFromNamespace] == source, nil
+}
+
+// OwnerLabels Build the labels used to track a secret written outside the namespace of its owner
+func OwnerLabels(name, ns string) map[string]string {
+	return map[string]string{
+		LabelOwnerName:      name,
+		LabelOwnerNamespace: ns,
+	}
+}
+
+// IsOwnedBy Check whether the labels of a secret mark it as written for the resource name in namespace ns
+func IsOwnedBy(sec *v1.Secret, name, ns string) bool {
+	labels := sec.GetLabels()
+	return labels[LabelOwnerName] == name && labels[LabelOwnerNamespace] == ns
+}
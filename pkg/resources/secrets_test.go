@@ -0,0 +1,136 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsSecretNamespaceAllowed(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	fakeClient := fake.NewFakeClientWithScheme(scheme, &v1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "labelled",
+			Labels: map[string]string{
+				LabelSecretsFromNamespace: "source",
+			},
+		},
+	}, &v1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "unlabelled",
+		},
+	})
+	cases := []struct {
+		name           string
+		source         string
+		target         string
+		client         client.Client
+		expectedResult bool
+		expectError    bool
+	}{
+		{
+			name:           "test same namespace is always allowed",
+			source:         "source",
+			target:         "source",
+			client:         fakeClient,
+			expectedResult: true,
+		},
+		{
+			name:           "test empty target namespace is allowed",
+			source:         "source",
+			target:         "",
+			client:         fakeClient,
+			expectedResult: true,
+		},
+		{
+			name:           "test namespace labelled with source namespace is allowed",
+			source:         "source",
+			target:         "labelled",
+			client:         fakeClient,
+			expectedResult: true,
+		},
+		{
+			name:           "test namespace labelled with other namespace is not allowed",
+			source:         "other",
+			target:         "labelled",
+			client:         fakeClient,
+			expectedResult: false,
+		},
+		{
+			name:           "test unlabelled namespace is not allowed",
+			source:         "source",
+			target:         "unlabelled",
+			client:         fakeClient,
+			expectedResult: false,
+		},
+		{
+			name:        "test error is returned when namespace doesn't exist",
+			source:      "source",
+			target:      "missing",
+			client:      fakeClient,
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, err := IsSecretNamespaceAllowed(context.TODO(), tc.client, tc.source, tc.target)
+			if err != nil {
+				if tc.expectError {
+					return
+				}
+				t.Fatal("unexpected error", err)
+			}
+			if tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if allowed != tc.expectedResult {
+				t.Fatalf("unexpected result, expected %t but got %t", tc.expectedResult, allowed)
+			}
+		})
+	}
+}
+
+func TestIsOwnedBy(t *testing.T) {
+	cases := []struct {
+		name           string
+		labels         map[string]string
+		expectedResult bool
+	}{
+		{
+			name:           "test returns true when owner labels match",
+			labels:         OwnerLabels("test", "test"),
+			expectedResult: true,
+		},
+		{
+			name:           "test returns false when owner labels are for another resource",
+			labels:         OwnerLabels("test2", "test"),
+			expectedResult: false,
+		},
+		{
+			name:           "test returns false when owner labels are missing",
+			labels:         nil,
+			expectedResult: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sec := &v1.Secret{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Labels: tc.labels,
+				},
+			}
+			if IsOwnedBy(sec, "test", "test") != tc.expectedResult {
+				t.Fatalf("unexpected result, expected %t but got %t", tc.expectedResult, IsOwnedBy(sec, "test", "test"))
+			}
+		})
+	}
+}
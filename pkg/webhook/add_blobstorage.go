@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/pkg/webhook/blobstorage"
+)
+
+func init() {
+	// AddToServerFuncs is a list of functions to build webhooks and register them with a server.
+	AddToServerFuncs = append(AddToServerFuncs, blobstorage.NewMutatingWebhook, blobstorage.NewValidatingWebhook)
+}
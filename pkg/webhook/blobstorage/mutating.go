@@ -0,0 +1,85 @@
+package blobstorage
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	runtimewebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/builder"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+// NewMutatingWebhook Build a webhook setting the defaults for fields a BlobStorage resource doesn't specify
+func NewMutatingWebhook(mgr manager.Manager) (runtimewebhook.Webhook, error) {
+	wh, err := builder.NewWebhookBuilder().
+		Name("mutating.blobstorages.integreatly.org").
+		Mutating().
+		Operations(admissionregistrationv1beta1.Create).
+		WithManager(mgr).
+		ForType(&v1alpha1.BlobStorage{}).
+		Handlers(&mutatingHandler{}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	return wh, nil
+}
+
+// mutatingHandler admission.Handler defaulting BlobStorage resources from the operator and namespace defaults
+type mutatingHandler struct {
+	client  client.Client
+	decoder atypes.Decoder
+}
+
+var _ admission.Handler = &mutatingHandler{}
+
+func (h *mutatingHandler) Handle(ctx context.Context, req atypes.Request) atypes.Response {
+	bs := &v1alpha1.BlobStorage{}
+	if err := h.decoder.Decode(req, bs); err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+	defaulted := bs.DeepCopy()
+	if defaulted.Namespace == "" {
+		defaulted.Namespace = req.AdmissionRequest.Namespace
+	}
+	if err := defaultBlobStorage(ctx, h.client, defaulted); err != nil {
+		return admission.ErrorResponse(http.StatusInternalServerError, err)
+	}
+	defaulted.Namespace = bs.Namespace
+	return admission.PatchResponse(bs, defaulted)
+}
+
+func (h *mutatingHandler) InjectClient(c client.Client) error {
+	h.client = c
+	return nil
+}
+
+func (h *mutatingHandler) InjectDecoder(d atypes.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// defaultBlobStorage Set the type and tier of an instance to the defaults for its namespace if they're not specified
+func defaultBlobStorage(ctx context.Context, c client.Client, bs *v1alpha1.BlobStorage) error {
+	if bs.Spec.Type != "" && bs.Spec.Tier != "" {
+		return nil
+	}
+	defaults, err := resources.GetDefaults(ctx, c, bs.Namespace)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get defaults for namespace %s", bs.Namespace)
+	}
+	if bs.Spec.Type == "" {
+		bs.Spec.Type = defaults.DeploymentType
+	}
+	if bs.Spec.Tier == "" {
+		bs.Spec.Tier = defaults.Tier
+	}
+	return nil
+}
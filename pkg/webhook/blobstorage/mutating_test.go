@@ -0,0 +1,69 @@
+package blobstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDefaultBlobStorage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	fakeClient := fake.NewFakeClientWithScheme(scheme, &v1.Namespace{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				resources.KeyDefaultDeploymentType: "managed",
+				resources.KeyDefaultTier:           "production",
+			},
+		},
+	})
+	cases := []struct {
+		name         string
+		deployType   string
+		tier         string
+		expectedType string
+		expectedTier string
+	}{
+		{
+			name:         "test namespace defaults are set when type and tier are empty",
+			expectedType: "managed",
+			expectedTier: "production",
+		},
+		{
+			name:         "test specified tier is not overridden",
+			tier:         "development",
+			expectedType: "managed",
+			expectedTier: "development",
+		},
+		{
+			name:         "test specified type and tier are not overridden",
+			deployType:   "workshop",
+			tier:         "development",
+			expectedType: "workshop",
+			expectedTier: "development",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bs := buildTestBlobStorage(tc.deployType, tc.tier, "test")
+			if err := defaultBlobStorage(context.TODO(), fakeClient, bs); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if bs.Spec.Type != tc.expectedType {
+				t.Fatalf("unexpected type, expected %s but got %s", tc.expectedType, bs.Spec.Type)
+			}
+			if bs.Spec.Tier != tc.expectedTier {
+				t.Fatalf("unexpected tier, expected %s but got %s", tc.expectedTier, bs.Spec.Tier)
+			}
+		})
+	}
+}
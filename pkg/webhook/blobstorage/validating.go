@@ -0,0 +1,140 @@
+package blobstorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	errorUtil "github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	runtimewebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/builder"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+// NewValidatingWebhook Build a webhook rejecting BlobStorage resources that can't be provisioned
+func NewValidatingWebhook(mgr manager.Manager) (runtimewebhook.Webhook, error) {
+	// the manager's cache only holds objects in the watched namespace, config is read from the cache shared with the
+	// controllers
+	cfgCache, err := providers.GetConfigCache(mgr)
+	if err != nil {
+		return nil, err
+	}
+	wh, err := builder.NewWebhookBuilder().
+		Name("validating.blobstorages.integreatly.org").
+		Validating().
+		Operations(admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update).
+		WithManager(mgr).
+		ForType(&v1alpha1.BlobStorage{}).
+		Handlers(&validatingHandler{cfgReader: cfgCache}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	return wh, nil
+}
+
+// validatingHandler admission.Handler validating BlobStorage resources against the provider config
+type validatingHandler struct {
+	client    client.Client
+	cfgReader client.Reader
+	decoder   atypes.Decoder
+}
+
+var _ admission.Handler = &validatingHandler{}
+
+func (h *validatingHandler) Handle(ctx context.Context, req atypes.Request) atypes.Response {
+	bs := &v1alpha1.BlobStorage{}
+	if err := h.decoder.Decode(req, bs); err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+	var old *v1alpha1.BlobStorage
+	if req.AdmissionRequest.Operation == admissionv1beta1.Update {
+		old = &v1alpha1.BlobStorage{}
+		if err := json.Unmarshal(req.AdmissionRequest.OldObject.Raw, old); err != nil {
+			return admission.ErrorResponse(http.StatusBadRequest, err)
+		}
+	}
+	providerList := []providers.BlobStorageProvider{aws.NewAWSBlobStorageProvider(h.client, aws.NewDefaultConfigManager(h.cfgReader))}
+	if err := validateBlobStorage(ctx, h.cfgReader, providerList, bs, old); err != nil {
+		return admission.ValidationResponse(false, err.Error())
+	}
+	return admission.ValidationResponse(true, "")
+}
+
+func (h *validatingHandler) InjectClient(c client.Client) error {
+	h.client = c
+	return nil
+}
+
+func (h *validatingHandler) InjectDecoder(d atypes.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// validateBlobStorage Check the spec of an instance can be provisioned, old is the existing instance on update and nil
+// on create, config is read through c
+func validateBlobStorage(ctx context.Context, c client.Reader, providerList []providers.BlobStorageProvider, bs *v1alpha1.BlobStorage, old *v1alpha1.BlobStorage) error {
+	specPath := field.NewPath("spec")
+	var errs field.ErrorList
+	if old != nil {
+		// allow metadata-only updates, e.g. finalizers being removed during deletion
+		if reflect.DeepEqual(old.Spec, bs.Spec) {
+			return nil
+		}
+		if old.Spec.Type != bs.Spec.Type {
+			errs = append(errs, field.Forbidden(specPath.Child("type"), "field can't be changed after creation"))
+		}
+		if old.Spec.Tier != bs.Spec.Tier {
+			errs = append(errs, field.Forbidden(specPath.Child("tier"), "field can't be changed after creation"))
+		}
+		if old.Spec.SecretRef != bs.Spec.SecretRef {
+			errs = append(errs, field.Forbidden(specPath.Child("secretRef"), "field can't be changed after creation"))
+		}
+	}
+	if bs.Spec.Type == "" {
+		errs = append(errs, field.Required(specPath.Child("type"), ""))
+	}
+	if bs.Spec.Tier == "" {
+		errs = append(errs, field.Required(specPath.Child("tier"), ""))
+	}
+	if bs.Spec.SecretRef.Name == "" {
+		errs = append(errs, field.Required(specPath.Child("secretRef", "name"), ""))
+	}
+	if len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+
+	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, providers.DefaultConfigNamespace, c)
+	stratMap, err := cfgMgr.GetStrategyMappingForDeploymentType(ctx, bs.Spec.Type)
+	if err != nil {
+		return field.Invalid(specPath.Child("type"), bs.Spec.Type, err.Error())
+	}
+	for _, p := range providerList {
+		if !p.SupportsStrategy(stratMap.BlobStorage) {
+			continue
+		}
+		exists, err := p.TierExists(ctx, bs.Spec.Tier)
+		if err != nil {
+			if errorUtil.Cause(err) == providers.ErrInvalidStrategy {
+				return field.Invalid(specPath.Child("tier"), bs.Spec.Tier, err.Error())
+			}
+			return field.InternalError(specPath.Child("tier"), err)
+		}
+		if !exists {
+			return field.NotFound(specPath.Child("tier"), bs.Spec.Tier)
+		}
+		return nil
+	}
+	return field.Invalid(specPath.Child("type"), bs.Spec.Type, fmt.Sprintf("unsupported deployment strategy %s", stratMap.BlobStorage))
+}
@@ -0,0 +1,126 @@
+package blobstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestBlobStorage(dt, tier, secret string) *v1alpha1.BlobStorage {
+	return &v1alpha1.BlobStorage{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.BlobStorageSpec{
+			Type: dt,
+			Tier: tier,
+			SecretRef: v1alpha1.SecretRef{
+				Name: secret,
+			},
+		},
+	}
+}
+
+func TestValidateBlobStorage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	fakeClient := fake.NewFakeClientWithScheme(scheme, &v1.ConfigMap{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      providers.DefaultProviderConfigMapName,
+			Namespace: providers.DefaultConfigNamespace,
+		},
+		Data: map[string]string{
+			"managed":  "{\"blobstorage\":\"aws\"}",
+			"workshop": "{\"blobstorage\":\"openshift\"}",
+		},
+	}, &v1.ConfigMap{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      aws.DefaultConfigMapName,
+			Namespace: aws.DefaultConfigMapNamespace,
+		},
+		Data: map[string]string{
+			"blobstorage": "{\"development\": {\"region\": \"eu-west-1\", \"strategy\": {}}}",
+		},
+	})
+	providerList := []providers.BlobStorageProvider{aws.NewAWSBlobStorageProvider(fakeClient, nil)}
+	cases := []struct {
+		name        string
+		bs          *v1alpha1.BlobStorage
+		old         *v1alpha1.BlobStorage
+		expectError bool
+	}{
+		{
+			name: "test valid instance is allowed",
+			bs:   buildTestBlobStorage("managed", "development", "test"),
+		},
+		{
+			name:        "test empty type is rejected",
+			bs:          buildTestBlobStorage("", "development", "test"),
+			expectError: true,
+		},
+		{
+			name:        "test empty tier is rejected",
+			bs:          buildTestBlobStorage("managed", "", "test"),
+			expectError: true,
+		},
+		{
+			name:        "test empty secret name is rejected",
+			bs:          buildTestBlobStorage("managed", "development", ""),
+			expectError: true,
+		},
+		{
+			name:        "test undefined deployment type is rejected",
+			bs:          buildTestBlobStorage("missing", "development", "test"),
+			expectError: true,
+		},
+		{
+			name:        "test unsupported deployment strategy is rejected",
+			bs:          buildTestBlobStorage("workshop", "development", "test"),
+			expectError: true,
+		},
+		{
+			name:        "test undefined tier is rejected",
+			bs:          buildTestBlobStorage("managed", "production", "test"),
+			expectError: true,
+		},
+		{
+			name:        "test changing tier is rejected",
+			bs:          buildTestBlobStorage("managed", "development", "test"),
+			old:         buildTestBlobStorage("managed", "production", "test"),
+			expectError: true,
+		},
+		{
+			name:        "test changing secret ref is rejected",
+			bs:          buildTestBlobStorage("managed", "development", "test"),
+			old:         buildTestBlobStorage("managed", "development", "test2"),
+			expectError: true,
+		},
+		{
+			name: "test update without spec changes is allowed",
+			bs:   buildTestBlobStorage("managed", "production", "test"),
+			old:  buildTestBlobStorage("managed", "production", "test"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBlobStorage(context.TODO(), fakeClient, providerList, tc.bs, tc.old)
+			if err != nil && !tc.expectError {
+				t.Fatal("unexpected error", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package webhook
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	runtimewebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const serverName = "cloud-resource-operator-admission-server"
+
+// AddToServerFuncs is a list of functions to build all Webhooks served by the operator
+var AddToServerFuncs []func(manager.Manager) (runtimewebhook.Webhook, error)
+
+// AddToManager builds all Webhooks, registers them with a webhook server and adds the server to the Manager
+func AddToManager(m manager.Manager, opts runtimewebhook.ServerOptions) error {
+	srv, err := runtimewebhook.NewServer(serverName, m, opts)
+	if err != nil {
+		return err
+	}
+	var webhooks []runtimewebhook.Webhook
+	for _, f := range AddToServerFuncs {
+		wh, err := f(m)
+		if err != nil {
+			return err
+		}
+		webhooks = append(webhooks, wh)
+	}
+	return srv.Register(webhooks...)
+}